@@ -2,27 +2,46 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/fritzkeyzer/mac-time-tracker/internal/config"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/control"
 	"github.com/fritzkeyzer/mac-time-tracker/internal/daemon"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/ingest"
 	"github.com/fritzkeyzer/mac-time-tracker/internal/logger"
 	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/timer"
 	"github.com/fritzkeyzer/mac-time-tracker/internal/tracker"
 	"github.com/fritzkeyzer/mac-time-tracker/internal/web_ui"
+	"github.com/fritzkeyzer/mac-time-tracker/pkg/unixsock"
 )
 
 const (
-	pollInterval   = 10 * time.Second
-	idleThreshold  = 5 * time.Minute
-	staleThreshold = 10 * time.Minute
+	// configCheckInterval is how often the daemon checks the config file for changes
+	configCheckInterval = 5 * time.Second
+
+	// ingestAddr is where the daemon accepts activity from local integrations (browser extension, editor plugins)
+	ingestAddr = "127.0.0.1:8081"
 )
 
+func init() {
+	// keep the main goroutine on the main thread, see tracker.RunMainLoop
+	runtime.LockOSThread()
+}
+
 func main() {
 	ctx := context.Background()
 
@@ -32,14 +51,10 @@ func main() {
 		os.Exit(1)
 	}
 
-	workDir := filepath.Join(homeDir, ".mac-time-tracker") // ~/.mac-time-tracker
-	logDir := filepath.Join(workDir, "logs")               // ~/.mac-time-tracker/logs
-	dbPath := filepath.Join(workDir, "tracker.sqlite")     // ~/.mac-time-tracker/tracker.sqlite
-
-	if err := os.MkdirAll(logDir, os.ModePerm); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create log dir: %v\n", err)
-		os.Exit(1)
-	}
+	workDir := filepath.Join(homeDir, ".mac-time-tracker")  // ~/.mac-time-tracker
+	configPath := filepath.Join(workDir, "config.json")     // ~/.mac-time-tracker/config.json
+	shellSocket := filepath.Join(workDir, "shell.sock")     // ~/.mac-time-tracker/shell.sock
+	controlSocket := filepath.Join(workDir, "control.sock") // ~/.mac-time-tracker/control.sock
 
 	// the default command is daemon
 	cmd := ""
@@ -50,9 +65,56 @@ func main() {
 		os.Exit(1)
 	}
 
+	// shell hooks run on every prompt, so they skip the log and DB setup below
+	switch cmd {
+	case "shell-init":
+		runShellInit(os.Args[2:])
+		return
+	case "shell-event":
+		runShellEvent(shellSocket, os.Args[2:])
+		return
+	case "status":
+		runStatus(controlSocket)
+		return
+	case "pause":
+		runPause(controlSocket, os.Args[2:])
+		return
+	case "resume":
+		runControl(controlSocket, control.Request{Command: control.CommandResume}, "Tracking resumed")
+		return
+	case "reload":
+		runControl(controlSocket, control.Request{Command: control.CommandReload}, "Reloaded")
+		return
+	case "uninstall":
+		runUninstall(workDir, loadConfigOrDefault(configPath, workDir).DataDir)
+		return
+	}
+
+	var cfg config.Config
+	if cmd == "init" {
+		cfg = loadConfigOrDefault(configPath, workDir)
+	} else {
+		cfg, err = config.Load(configPath, workDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	logDir := filepath.Join(cfg.DataDir, "logs")           // ~/.mac-time-tracker/logs
+	dbPath := filepath.Join(cfg.DataDir, "tracker.sqlite") // ~/.mac-time-tracker/tracker.sqlite
+
+	if err := os.MkdirAll(logDir, os.ModePerm); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create log dir: %v\n", err)
+		os.Exit(1)
+	}
+
 	// init slog
+	logLevel := new(slog.LevelVar)
+	level, _ := cfg.Level() // validated by Load
+	logLevel.Set(level)
 	logWriter := &logger.DailyLogWriter{Dir: logDir}
-	handler := slog.NewJSONHandler(logWriter, &slog.HandlerOptions{Level: slog.LevelDebug})
+	handler := slog.NewJSONHandler(logWriter, &slog.HandlerOptions{Level: logLevel})
 	l := slog.New(handler).With("cmd", cmd)
 	slog.SetDefault(l)
 
@@ -66,15 +128,21 @@ func main() {
 
 	switch cmd {
 	case "daemon":
-		runDaemon(ctx, db)
+		runDaemon(ctx, db, cfg, configPath, workDir, logLevel, shellSocket, controlSocket)
 	case "init":
-		runInit(logDir, workDir)
+		runInit(logDir, workDir, configPath)
 	case "logs":
 		runLogs(logDir)
 	case "open":
-		runOpen(ctx, db)
-	case "uninstall":
-		runUninstall()
+		runOpen(ctx, db, cfg.WebAddr)
+	case "start":
+		runStart(ctx, db, os.Args[2:])
+	case "stop":
+		runStop(ctx, db)
+	case "gaps":
+		runGaps(ctx, db)
+	case "resolve":
+		runResolve(ctx, db, os.Args[2:])
 	default:
 		fmt.Printf("Unknown command: %s\n", cmd)
 		printUsage()
@@ -82,45 +150,160 @@ func main() {
 	}
 }
 
+// loadConfigOrDefault loads the config for the commands that must work while it's broken, so the app can still be
+// reinstalled or removed.
+func loadConfigOrDefault(configPath, workDir string) config.Config {
+	cfg, err := config.Load(configPath, workDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config, using the defaults: %v\n", err)
+		return config.Default(workDir)
+	}
+	return cfg
+}
+
 func printUsage() {
 	fmt.Println("Usage: mac-time-tracker <command>")
 	fmt.Println("Commands:")
 	fmt.Println("  daemon     Run the tracker daemon")
+	fmt.Println("  gaps       List the away periods waiting to be resolved")
 	fmt.Println("  init       Install LaunchAgent")
 	fmt.Println("  logs       Tail logs")
 	fmt.Println("  open       Open web UI")
+	fmt.Println("  pause      Pause tracking, for a duration (e.g. 30m) or until resumed")
+	fmt.Println("  reload     Reload the daemon configuration")
+	fmt.Println("  resolve    Say what you were doing during an away period")
+	fmt.Println("  resume     Resume tracking")
+	fmt.Println("  shell-init Print the prompt hook for zsh, bash or fish")
+	fmt.Println("  start      Start a manual timer for a project, with an optional note")
+	fmt.Println("  status     Show what the daemon is tracking")
+	fmt.Println("  stop       Stop the manual timer")
 	fmt.Println("  uninstall  Remove app bundle, plist, and optionally user data")
 }
 
-func runDaemon(ctx context.Context, db *store.Queries) {
+func runDaemon(
+	ctx context.Context,
+	db *store.Queries,
+	cfg config.Config,
+	configPath, workDir string,
+	logLevel *slog.LevelVar,
+	shellSocket, controlSocket string,
+) {
 	// Handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 
-	slog.Info("Daemon started")
+	// a second daemon would record every span twice
+	if unixsock.InUse(controlSocket) {
+		slog.Error("Daemon already running", "socket", controlSocket)
+		os.Exit(1)
+	}
 
-	// Initial collection
-	if err := tracker.CollectAndLog(ctx, db, idleThreshold, staleThreshold); err != nil {
-		slog.Error("Error collecting initial data", "error", err)
+	sources, err := tracker.DefaultSources()
+	if err != nil {
+		slog.Error("Failed to init tracker sources", "error", err)
+		os.Exit(1)
 	}
+	t := tracker.New(db, sources, cfg.IdleThreshold.D(), cfg.StaleThreshold.D())
+	t.Reconfigure(trackerSettings(cfg))
+
+	slog.Info("Daemon started", "config", cfg)
+
+	// reload the config when the file changes, on SIGHUP and on `mac-time-tracker reload`
+	configWatcher := config.NewWatcher(configPath, workDir, cfg, func(cfg config.Config) {
+		level, _ := cfg.Level()
+		logLevel.Set(level)
+		t.Reconfigure(trackerSettings(cfg))
+	})
+	go configWatcher.Run(ctx, configCheckInterval)
+
+	// the tracker keeps working without integrations, e.g. if another daemon holds the port
+	ingestServer := ingest.NewServer(t, db, ingestAddr)
+	go func() {
+		if err := ingestServer.Start(ctx); err != nil {
+			slog.Error("Ingest server error", "error", err)
+		}
+	}()
+	go func() {
+		if err := ingestServer.ServeShell(ctx, shellSocket); err != nil {
+			slog.Error("Shell socket error", "error", err)
+		}
+	}()
 
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
+	controlServer := control.NewServer(t, configWatcher.Reload)
+	go func() {
+		if err := controlServer.Serve(ctx, controlSocket); err != nil {
+			slog.Error("Control socket error", "error", err)
+		}
+	}()
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := tracker.CollectAndLog(ctx, db, idleThreshold, staleThreshold); err != nil {
-				slog.Error("Error collecting data", "error", err)
-			}
-		case <-sigChan:
-			slog.Info("Shutting down")
-			return
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t.Run(ctx, cfg.PollInterval.D())
+	}()
+
+	// macOS delivers focus notifications through the main run loop, which has to run on the main thread
+	tracker.RunMainLoop(ctx)
+	<-done
+
+	slog.Info("Shutting down")
+}
+
+// trackerSettings returns the tracker settings from cfg, which must be valid.
+func trackerSettings(cfg config.Config) tracker.Settings {
+	rules := make([]tracker.PrivacyRule, 0, len(cfg.Privacy.Rules))
+	for _, rule := range cfg.Privacy.Rules {
+		r := tracker.PrivacyRule{Action: rule.Action}
+		if rule.App != "" {
+			r.App = regexp.MustCompile(rule.App)
 		}
+		if rule.Title != "" {
+			r.Title = regexp.MustCompile(rule.Title)
+		}
+		rules = append(rules, r)
+	}
+
+	rewrites := make([]tracker.TitleRewrite, 0, len(cfg.Titles.Rewrites))
+	for _, rewrite := range cfg.Titles.Rewrites {
+		r := tracker.TitleRewrite{
+			Pattern: regexp.MustCompile(rewrite.Pattern),
+			Replace: rewrite.Replace,
+		}
+		if rewrite.App != "" {
+			r.App = regexp.MustCompile(rewrite.App)
+		}
+		rewrites = append(rewrites, r)
+	}
+
+	return tracker.Settings{
+		PollInterval:   cfg.PollInterval.D(),
+		IdleThreshold:  cfg.IdleThreshold.D(),
+		StaleThreshold: cfg.StaleThreshold.D(),
+		MinGap:         cfg.MinGap.D(),
+		Privacy: tracker.Privacy{
+			DropBrowserURLs:      !cfg.Privacy.StoreBrowserURLs,
+			DropTerminalCommands: !cfg.Privacy.StoreTerminalCommands,
+			DetectPrivateWindows: cfg.Privacy.DetectPrivateWindows,
+			Rules:                rules,
+		},
+		Titles: tracker.Titles{
+			Normalize: cfg.Titles.Normalize,
+			Rewrites:  rewrites,
+			KeepRaw:   cfg.Titles.KeepRaw,
+		},
 	}
 }
 
-func runInit(logDir, workDir string) {
+func runInit(logDir, workDir, configPath string) {
+	// write the defaults so there is a file to edit
+	if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+		if err := config.Default(workDir).Write(configPath); err != nil {
+			slog.Error("Failed to write default config", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote default config: %s\n", configPath)
+	}
+
 	if err := daemon.InstallLaunchAgent(logDir, workDir); err != nil {
 		slog.Error("Failed to install launch agent", "error", err)
 		os.Exit(1)
@@ -134,12 +317,12 @@ func runLogs(logDir string) {
 	}
 }
 
-func runOpen(ctx context.Context, db *store.Queries) {
+func runOpen(ctx context.Context, db *store.Queries, webAddr string) {
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	server := web_ui.NewServer(db, "8080")
+	server := web_ui.NewServer(db, webAddr)
 
 	// Start server in goroutine
 	go func() {
@@ -154,7 +337,200 @@ func runOpen(ctx context.Context, db *store.Queries) {
 	slog.Info("Shutting down web server")
 }
 
-func runUninstall() {
+func runShellInit(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: mac-time-tracker shell-init zsh|bash|fish")
+		os.Exit(1)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get executable path: %v\n", err)
+		os.Exit(1)
+	}
+
+	hook, err := ingest.ShellInit(args[0], exe)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Print(hook)
+}
+
+func runShellEvent(shellSocket string, args []string) {
+	fs := flag.NewFlagSet("shell-event", flag.ExitOnError)
+	pid := fs.Int("pid", os.Getppid(), "process id of the shell")
+	cwd := fs.String("cwd", "", "working directory of the shell")
+	command := fs.String("command", "", "command about to run, empty at the prompt")
+	_ = fs.Parse(args)
+
+	if *cwd == "" {
+		*cwd, _ = os.Getwd()
+	}
+
+	// errors are ignored: the hook must never disturb the shell, e.g. while the daemon isn't running
+	_ = ingest.SendShellEvent(shellSocket, ingest.ShellEvent{
+		PID:     int32(*pid),
+		Cwd:     *cwd,
+		GitRoot: tracker.GitRoot(*cwd),
+		Command: *command,
+	})
+}
+
+func runStatus(controlSocket string) {
+	resp, err := control.Call(controlSocket, control.Request{Command: control.CommandStatus})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get status: %v\n", err)
+		os.Exit(1)
+	}
+	status := resp.Status
+	if status == nil {
+		fmt.Fprintln(os.Stderr, "Failed to get status: empty response")
+		os.Exit(1)
+	}
+
+	switch {
+	case status.Paused && status.PausedUntil != nil:
+		fmt.Printf("Paused until %s\n", status.PausedUntil.Format(time.TimeOnly))
+	case status.Paused:
+		fmt.Println("Paused until resumed")
+	default:
+		fmt.Println("Tracking")
+	}
+
+	switch {
+	case status.CurrentSpan != nil:
+		span := status.CurrentSpan
+		fmt.Printf("Current:    %s - %s (%s)\n", span.AppName, span.WindowTitle,
+			time.Duration(span.EndAt-span.StartAt)*time.Second)
+	case status.CurrentAway != nil:
+		away := status.CurrentAway
+		fmt.Printf("Away:       %s (%s)\n", away.Kind, time.Duration(away.EndAt-away.StartAt)*time.Second)
+	}
+	if status.LastPoll != nil {
+		fmt.Printf("Last poll:  %s\n", status.LastPoll.Format(time.TimeOnly))
+	}
+	if status.LastError != "" {
+		fmt.Printf("Last error: %s (%s)\n", status.LastError, status.LastErrorAt.Format(time.DateTime))
+	}
+}
+
+func runPause(controlSocket string, args []string) {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: mac-time-tracker pause [duration]")
+		os.Exit(1)
+	}
+
+	req := control.Request{Command: control.CommandPause}
+	msg := "Tracking paused until resumed"
+	if len(args) == 1 {
+		req.Duration = args[0]
+		msg = "Tracking paused for " + args[0]
+	}
+	runControl(controlSocket, req, msg)
+}
+
+func runControl(controlSocket string, req control.Request, msg string) {
+	if _, err := control.Call(controlSocket, req); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to %s: %v\n", req.Command, err)
+		os.Exit(1)
+	}
+	fmt.Println(msg)
+}
+
+func runStart(ctx context.Context, db *store.Queries, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: mac-time-tracker start <project> [note]")
+		os.Exit(1)
+	}
+
+	project := findProject(ctx, db, args[0])
+	_, stopped, err := timer.Start(ctx, db, project.ID, strings.Join(args[1:], " "), time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start timer: %v\n", err)
+		os.Exit(1)
+	}
+	if stopped.ID > 0 {
+		fmt.Printf("Stopped the previous timer after %s\n", time.Duration(stopped.EndAt-stopped.StartAt)*time.Second)
+	}
+	fmt.Printf("Timer started for %s\n", project.Name)
+}
+
+func runStop(ctx context.Context, db *store.Queries) {
+	entry, err := timer.Stop(ctx, db, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to stop timer: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Timer stopped after %s\n", time.Duration(entry.EndAt-entry.StartAt)*time.Second)
+}
+
+func runGaps(ctx context.Context, db *store.Queries) {
+	gaps, err := db.SelectPendingGaps(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list gaps: %v\n", err)
+		os.Exit(1)
+	}
+	if len(gaps) == 0 {
+		fmt.Println("No gaps to resolve")
+		return
+	}
+
+	for _, gap := range gaps {
+		start, end := time.Unix(gap.StartAt, 0), time.Unix(gap.EndAt, 0)
+		fmt.Printf("%4d  %s - %s  %-8s %s\n", gap.ID, start.Format("Mon 02 Jan 15:04"), end.Format("15:04"),
+			time.Duration(gap.EndAt-gap.StartAt)*time.Second, gap.Kind)
+	}
+	fmt.Println()
+	fmt.Println("Resolve with: mac-time-tracker resolve <id> project <name>|break|meeting|discard [note]")
+}
+
+func runResolve(ctx context.Context, db *store.Queries, args []string) {
+	usage := "Usage: mac-time-tracker resolve <id> project <name>|break|meeting|discard [note]"
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(1)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(1)
+	}
+
+	res := timer.Resolution{Kind: args[1]}
+	rest := args[2:]
+	if res.Kind == timer.KindProject {
+		if len(rest) == 0 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(1)
+		}
+		res.ProjectID = findProject(ctx, db, rest[0]).ID
+		rest = rest[1:]
+	}
+	res.Note = strings.Join(rest, " ")
+
+	if _, err := timer.ResolveGap(ctx, db, id, res); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve gap: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Gap %d resolved\n", id)
+}
+
+// findProject returns the project with the given name, ignoring case, or exits.
+func findProject(ctx context.Context, db *store.Queries, name string) store.Project {
+	project, err := db.SelectProjectByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Fprintf(os.Stderr, "Unknown project %q, projects are created in the web UI (mac-time-tracker open)\n", name)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to find project: %v\n", err)
+		os.Exit(1)
+	}
+	return project
+}
+
+func runUninstall(workDir, dataDir string) {
 	fmt.Println("MacTimeTracker Uninstaller")
 	fmt.Println("==========================")
 	fmt.Println()
@@ -162,7 +538,10 @@ func runUninstall() {
 	fmt.Println("  - App bundle (~/Applications/MacTimeTracker.app)")
 	fmt.Println("  - LaunchAgent plist")
 	fmt.Println()
-	fmt.Print("Do you also want to remove your data (database and logs)? [y/N]: ")
+	fmt.Println("Your data is stored in:")
+	fmt.Printf("  - %s (config)\n", workDir)
+	fmt.Printf("  - %s (database and logs)\n", dataDir)
+	fmt.Print("Do you also want to remove your data? [y/N]: ")
 
 	var response string
 	fmt.Scanln(&response)
@@ -173,7 +552,7 @@ func runUninstall() {
 	}
 
 	fmt.Println()
-	if err := daemon.UninstallLaunchAgent(removeData); err != nil {
+	if err := daemon.UninstallLaunchAgent(dataDir, removeData); err != nil {
 		slog.Error("Failed to uninstall", "error", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,54 @@
+// Reports the active tab of the focused browser window to the mac-time-tracker daemon.
+// The daemon matches the tab title against the focused window title (see internal/tracker/browser.go).
+const ENDPOINT = 'http://127.0.0.1:8081/api/browser/tab';
+
+const api = globalThis.browser ?? globalThis.chrome;
+
+let lastReport = '';
+
+function report(tab) {
+    if (!tab || !tab.url)
+        return;
+
+    const body = JSON.stringify({
+        url: tab.url,
+        domain: domainOf(tab.url),
+        title: tab.title || '',
+        incognito: tab.incognito,
+    });
+    if (body === lastReport)
+        return;
+    lastReport = body;
+
+    fetch(ENDPOINT, {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json'},
+        body,
+    }).catch(() => {
+        // the daemon isn't running, send again on the next change
+        lastReport = '';
+    });
+}
+
+function domainOf(url) {
+    try {
+        return new URL(url).hostname.replace(/^www\./, '');
+    } catch {
+        return '';
+    }
+}
+
+async function reportActiveTab() {
+    const [tab] = await api.tabs.query({active: true, lastFocusedWindow: true});
+    report(tab);
+}
+
+api.tabs.onActivated.addListener(reportActiveTab);
+api.windows.onFocusChanged.addListener(windowId => {
+    if (windowId !== api.windows.WINDOW_ID_NONE)
+        reportActiveTab();
+});
+api.tabs.onUpdated.addListener((tabId, changeInfo, tab) => {
+    if (tab.active && (changeInfo.url || changeInfo.title))
+        reportActiveTab();
+});
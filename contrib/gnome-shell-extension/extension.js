@@ -0,0 +1,59 @@
+// Reports the focused window to the mac-time-tracker daemon.
+// The daemon exports com.fritzkeyzer.MacTimeTracker.Window on the session bus (see internal/tracker/windows_dbus_linux.go).
+import Gio from 'gi://Gio';
+import GLib from 'gi://GLib';
+import Shell from 'gi://Shell';
+import {Extension} from 'resource:///org/gnome/shell/extensions/extension.js';
+
+const BUS_NAME = 'com.fritzkeyzer.MacTimeTracker';
+const OBJECT_PATH = '/com/fritzkeyzer/MacTimeTracker/Window';
+const INTERFACE = 'com.fritzkeyzer.MacTimeTracker.Window';
+
+export default class MacTimeTrackerExtension extends Extension {
+    enable() {
+        this._window = null;
+        this._titleId = 0;
+        this._focusId = global.display.connect('notify::focus-window', () => this._onFocusChanged());
+        // re-send the focused window whenever the daemon (re)starts
+        this._watchId = Gio.bus_watch_name(Gio.BusType.SESSION, BUS_NAME, Gio.BusNameWatcherFlags.NONE,
+            () => this._report(), null);
+        this._onFocusChanged();
+    }
+
+    disable() {
+        global.display.disconnect(this._focusId);
+        Gio.bus_unwatch_name(this._watchId);
+        this._disconnectTitle();
+        this._window = null;
+    }
+
+    _onFocusChanged() {
+        this._disconnectTitle();
+        this._window = global.display.focus_window;
+        if (this._window)
+            this._titleId = this._window.connect('notify::title', () => this._report());
+        this._report();
+    }
+
+    _disconnectTitle() {
+        if (this._window && this._titleId)
+            this._window.disconnect(this._titleId);
+        this._titleId = 0;
+    }
+
+    _report() {
+        const win = this._window;
+        let appName = '', appId = '', title = '', pid = 0;
+        if (win) {
+            const app = Shell.WindowTracker.get_default().get_window_app(win);
+            appName = app ? app.get_name() : (win.get_wm_class() ?? '');
+            appId = app?.get_id() ?? win.get_wm_class() ?? '';
+            title = win.get_title() ?? '';
+            pid = Math.max(win.get_pid(), 0);
+        }
+
+        Gio.DBus.session.call(BUS_NAME, OBJECT_PATH, INTERFACE, 'Update',
+            new GLib.Variant('(sssu)', [appName, appId, title, pid]),
+            null, Gio.DBusCallFlags.NO_AUTO_START, -1, null, null);
+    }
+}
@@ -0,0 +1,37 @@
+// Reports the focused window to the mac-time-tracker daemon.
+// The daemon exports com.fritzkeyzer.MacTimeTracker.Window on the session bus (see internal/tracker/windows_dbus_linux.go).
+const BUS_NAME = "com.fritzkeyzer.MacTimeTracker";
+const OBJECT_PATH = "/com/fritzkeyzer/MacTimeTracker/Window";
+const INTERFACE = "com.fritzkeyzer.MacTimeTracker.Window";
+
+let current = null;
+
+function report() {
+    const w = current;
+    if (!w) {
+        callDBus(BUS_NAME, OBJECT_PATH, INTERFACE, "Update", "", "", "", 0);
+        return;
+    }
+    callDBus(BUS_NAME, OBJECT_PATH, INTERFACE, "Update",
+        w.resourceClass || "", w.desktopFileName || w.resourceName || "", w.caption || "", w.pid || 0);
+}
+
+function onActivated(w) {
+    if (current) {
+        current.captionChanged.disconnect(report);
+    }
+    current = w;
+    if (current) {
+        current.captionChanged.connect(report);
+    }
+    report();
+}
+
+// Plasma 6 renamed clientActivated/activeClient to windowActivated/activeWindow
+if (workspace.windowActivated) {
+    workspace.windowActivated.connect(onActivated);
+    onActivated(workspace.activeWindow);
+} else {
+    workspace.clientActivated.connect(onActivated);
+    onActivated(workspace.activeClient);
+}
@@ -4,5 +4,7 @@ go 1.25
 
 require (
 	github.com/gobigbang/binder v0.0.3
+	github.com/godbus/dbus/v5 v5.1.0
+	github.com/jezek/xgb v1.1.1
 	github.com/mattn/go-sqlite3 v1.14.33
 )
@@ -0,0 +1,178 @@
+// Package audit records the changes made to projects, categories, rules and spans in an append-only log. Each entry
+// holds the changed rows before and after the change, so it can be undone by restoring the rows from before.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// ActionUndo is the action of the entries recorded by Undo.
+const ActionUndo = "undo"
+
+var (
+	// ErrUndone is returned when undoing an entry that was already undone.
+	ErrUndone = errors.New("change already undone")
+	// ErrChanged is returned when undoing an entry whose rows were changed since, those changes must be undone first.
+	ErrChanged = errors.New("rows changed since, undo the later changes first")
+)
+
+// Record runs fn in a transaction and logs the change it makes to the rows of target as action, e.g. project.delete.
+// fn returns the rows it created, which are added to the target.
+func Record(ctx context.Context, db *store.Queries, action string, target Target, fn func(q *store.Queries) (Target, error)) error {
+	return db.InTx(ctx, func(q *store.Queries) error {
+		before, err := capture(ctx, q, target)
+		if err != nil {
+			return err
+		}
+		created, err := fn(q)
+		if err != nil {
+			return err
+		}
+		target = target.add(created)
+		after, err := capture(ctx, q, target)
+		if err != nil {
+			return err
+		}
+
+		latest, err := q.SelectLatestSpan(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("select latest span: %w", err)
+		}
+		if latest.ID > 0 && slices.Contains(target.SpanIDs, latest.ID) {
+			target.OpenSpanID = latest.ID
+		}
+		_, err = insertEntry(ctx, q, action, target, before, after, nil)
+		return err
+	})
+}
+
+// Undo reverts the change logged in an entry by restoring its rows from before, and logs the undo as a new entry,
+// which can itself be undone. It returns the new entry.
+func Undo(ctx context.Context, db *store.Queries, id int64) (store.AuditLog, error) {
+	var undo store.AuditLog
+	err := db.InTx(ctx, func(q *store.Queries) error {
+		entry, err := q.SelectAuditLog(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("audit entry %d not found", id)
+		}
+		if err != nil {
+			return fmt.Errorf("select audit log: %w", err)
+		}
+		if entry.UndoneBy != nil {
+			return fmt.Errorf("%w by entry %d", ErrUndone, *entry.UndoneBy)
+		}
+
+		var target Target
+		if err := json.Unmarshal([]byte(entry.Target), &target); err != nil {
+			return fmt.Errorf("unmarshal target: %w", err)
+		}
+		var before, after State
+		if err := json.Unmarshal([]byte(entry.Before), &before); err != nil {
+			return fmt.Errorf("unmarshal before: %w", err)
+		}
+		if err := json.Unmarshal([]byte(entry.After), &after); err != nil {
+			return fmt.Errorf("unmarshal after: %w", err)
+		}
+
+		current, err := capture(ctx, q, target)
+		if err != nil {
+			return err
+		}
+		compared, before := followOpenSpan(current, after, before, target.OpenSpanID)
+		comparedJSON, err := json.Marshal(compared)
+		if err != nil {
+			return fmt.Errorf("marshal state: %w", err)
+		}
+		if !bytes.Equal(comparedJSON, []byte(entry.After)) {
+			return ErrChanged
+		}
+
+		if err := restore(ctx, q, current, before); err != nil {
+			return err
+		}
+		undo, err = insertEntry(ctx, q, ActionUndo, target, current, before, &entry.ID)
+		return err
+	})
+	return undo, err
+}
+
+// followOpenSpan accounts for the tracker writing to the open span since the change: it moves the end of the span and
+// re-attaches its context (attributes, browser tab, terminal) every poll. It returns current with the open span as it
+// was after the change, to compare with after, and before with the tracker's context kept on the open span and its
+// end carried over to the span of before that ended last, so undoing doesn't drop tracked time.
+func followOpenSpan(current, after, before State, openSpanID int64) (State, State) {
+	if openSpanID == 0 {
+		return current, before
+	}
+	curIdx := slices.IndexFunc(current.Spans, func(s Span) bool { return s.Span.ID == openSpanID })
+	afterIdx := slices.IndexFunc(after.Spans, func(s Span) bool { return s.Span.ID == openSpanID })
+	if curIdx < 0 || afterIdx < 0 {
+		return current, before
+	}
+	tracked := current.Spans[curIdx]
+	afterSpan := after.Spans[afterIdx]
+
+	current.Spans = slices.Clone(current.Spans)
+	open := &current.Spans[curIdx]
+	open.Span.EndAt = afterSpan.Span.EndAt
+	open.BrowserTab, open.Terminal, open.Attributes = afterSpan.BrowserTab, afterSpan.Terminal, afterSpan.Attributes
+
+	if len(before.Spans) == 0 {
+		return current, before
+	}
+	before.Spans = slices.Clone(before.Spans)
+	if i := slices.IndexFunc(before.Spans, func(s Span) bool { return s.Span.ID == openSpanID }); i >= 0 {
+		span := &before.Spans[i]
+		span.BrowserTab, span.Terminal, span.Attributes = tracked.BrowserTab, tracked.Terminal, tracked.Attributes
+	}
+
+	if tracked.Span.EndAt == afterSpan.Span.EndAt {
+		return current, before
+	}
+	last := 0
+	for i, span := range before.Spans {
+		if span.Span.EndAt > before.Spans[last].Span.EndAt {
+			last = i
+		}
+	}
+	span := &before.Spans[last].Span
+	span.EndAt = max(tracked.Span.EndAt, span.EndAt)
+	return current, before
+}
+
+func insertEntry(ctx context.Context, q *store.Queries, action string, target Target, before, after State, undoes *int64) (store.AuditLog, error) {
+	targetJSON, err := json.Marshal(target)
+	if err != nil {
+		return store.AuditLog{}, fmt.Errorf("marshal target: %w", err)
+	}
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return store.AuditLog{}, fmt.Errorf("marshal before: %w", err)
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return store.AuditLog{}, fmt.Errorf("marshal after: %w", err)
+	}
+
+	entry, err := q.InsertAuditLog(ctx, store.InsertAuditLogParams{
+		At:     time.Now().Unix(),
+		Action: action,
+		Target: string(targetJSON),
+		Before: string(beforeJSON),
+		After:  string(afterJSON),
+		Undoes: undoes,
+	})
+	if err != nil {
+		return entry, fmt.Errorf("insert audit log: %w", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,264 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/spanedit"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// newTestDB returns a database with the project Acme (1) and its rule (1), the spans Code 100-200 (1, pinned to Acme,
+// with an attribute) and Slack 300-400 (2, the latest), and a manual entry for Acme (1).
+func newTestDB(t *testing.T) *store.Queries {
+	t.Helper()
+	ctx := context.Background()
+	db, closeDB, err := store.InitDB(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatalf("InitDB() error = %v", err)
+	}
+	t.Cleanup(closeDB)
+
+	project, err := db.InsertProject(ctx, store.InsertProjectParams{Name: "Acme", Color: "#ff0000"})
+	if err != nil {
+		t.Fatalf("InsertProject() error = %v", err)
+	}
+	if _, err := db.InsertProjectRule(ctx, store.InsertProjectRuleParams{Pattern: "Code", ProjectID: project.ID, IsActive: true}); err != nil {
+		t.Fatalf("InsertProjectRule() error = %v", err)
+	}
+	for _, span := range []store.InsertSpanParams{
+		{AppName: "Code", StartAt: 100, EndAt: 200},
+		{AppName: "Slack", StartAt: 300, EndAt: 400},
+	} {
+		if _, err := db.InsertSpan(ctx, span); err != nil {
+			t.Fatalf("InsertSpan() error = %v", err)
+		}
+	}
+	if err := db.UpsertSpanAttribute(ctx, store.UpsertSpanAttributeParams{SpanID: 1, Key: "cwd", Value: "/src", Type: "string"}); err != nil {
+		t.Fatalf("UpsertSpanAttribute() error = %v", err)
+	}
+	if err := db.UpsertSpanPin(ctx, store.UpsertSpanPinParams{SpanID: 1, ProjectID: &project.ID}); err != nil {
+		t.Fatalf("UpsertSpanPin() error = %v", err)
+	}
+	if _, err := db.InsertManualEntry(ctx, store.InsertManualEntryParams{Kind: "project", ProjectID: &project.ID, StartAt: 500, EndAt: 600}); err != nil {
+		t.Fatalf("InsertManualEntry() error = %v", err)
+	}
+	return db
+}
+
+// snapshot returns the rows of the test database as JSON, including those the tests create.
+func snapshot(t *testing.T, db *store.Queries) string {
+	t.Helper()
+	ids := []int64{1, 2, 3, 4}
+	state, err := capture(context.Background(), db, Target{
+		ProjectIDs:     ids,
+		ProjectRuleIDs: ids,
+		SpanIDs:        ids,
+		ManualEntryIDs: ids,
+	})
+	if err != nil {
+		t.Fatalf("capture() error = %v", err)
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+	return string(b)
+}
+
+func latestEntryID(t *testing.T, db *store.Queries) int64 {
+	t.Helper()
+	entries, err := db.SelectAuditLogs(context.Background(), store.SelectAuditLogsParams{BeforeID: math.MaxInt64, Limit: 1})
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("SelectAuditLogs() = %v, %v", entries, err)
+	}
+	return entries[0].ID
+}
+
+func TestUndoRoundTrip(t *testing.T) {
+	acme := int64(1)
+
+	tests := []struct {
+		name   string
+		target Target
+		fn     func(ctx context.Context, q *store.Queries) (Target, error)
+	}{
+		{
+			name:   "delete project",
+			target: Target{ProjectIDs: []int64{1}, ProjectRuleIDs: []int64{1}, SpanIDs: []int64{1}, ManualEntryIDs: []int64{1}},
+			fn: func(ctx context.Context, q *store.Queries) (Target, error) {
+				if err := q.DeleteProjectRule(ctx, 1); err != nil {
+					return Target{}, err
+				}
+				if err := q.ClearSpanPinProject(ctx, &acme); err != nil {
+					return Target{}, err
+				}
+				if err := q.DeleteEmptySpanPins(ctx); err != nil {
+					return Target{}, err
+				}
+				if err := q.ClearManualEntryProject(ctx, &acme); err != nil {
+					return Target{}, err
+				}
+				return Target{}, q.DeleteProject(ctx, 1)
+			},
+		},
+		{
+			name:   "insert project",
+			target: Target{},
+			fn: func(ctx context.Context, q *store.Queries) (Target, error) {
+				project, err := q.InsertProject(ctx, store.InsertProjectParams{Name: "Beta", Color: "#00ff00"})
+				return Target{ProjectIDs: []int64{project.ID}}, err
+			},
+		},
+		{
+			name:   "trim span",
+			target: Target{SpanIDs: []int64{1}},
+			fn: func(ctx context.Context, q *store.Queries) (Target, error) {
+				_, err := spanedit.Trim(ctx, q, 1, 120, 180)
+				return Target{}, err
+			},
+		},
+		{
+			name:   "split span",
+			target: Target{SpanIDs: []int64{1}},
+			fn: func(ctx context.Context, q *store.Queries) (Target, error) {
+				_, second, err := spanedit.Split(ctx, q, 1, 150)
+				return Target{SpanIDs: []int64{second.ID}}, err
+			},
+		},
+		{
+			name:   "merge spans",
+			target: Target{SpanIDs: []int64{1, 2}},
+			fn: func(ctx context.Context, q *store.Queries) (Target, error) {
+				_, err := spanedit.Merge(ctx, q, 1, 2)
+				return Target{}, err
+			},
+		},
+		{
+			name:   "delete span",
+			target: Target{SpanIDs: []int64{1}},
+			fn: func(ctx context.Context, q *store.Queries) (Target, error) {
+				return Target{}, spanedit.Delete(ctx, q, 1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			before := snapshot(t, db)
+
+			err := Record(ctx, db, "test", tt.target, func(q *store.Queries) (Target, error) {
+				return tt.fn(ctx, q)
+			})
+			if err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			after := snapshot(t, db)
+			if after == before {
+				t.Fatalf("Record() changed nothing")
+			}
+
+			undo, err := Undo(ctx, db, latestEntryID(t, db))
+			if err != nil {
+				t.Fatalf("Undo() error = %v", err)
+			}
+			if got := snapshot(t, db); got != before {
+				t.Errorf("after undo\n got  %s\n want %s", got, before)
+			}
+
+			// an undo can itself be undone
+			if _, err := Undo(ctx, db, undo.ID); err != nil {
+				t.Fatalf("Undo() of the undo error = %v", err)
+			}
+			if got := snapshot(t, db); got != after {
+				t.Errorf("after undoing the undo\n got  %s\n want %s", got, after)
+			}
+		})
+	}
+}
+
+func TestUndoTwice(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	err := Record(ctx, db, "span.delete", Target{SpanIDs: []int64{1}}, func(q *store.Queries) (Target, error) {
+		return Target{}, spanedit.Delete(ctx, q, 1)
+	})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	id := latestEntryID(t, db)
+	if _, err := Undo(ctx, db, id); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if _, err := Undo(ctx, db, id); !errors.Is(err, ErrUndone) {
+		t.Errorf("second Undo() error = %v, want %v", err, ErrUndone)
+	}
+}
+
+func TestUndoChangedSince(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, end := range []int64{180, 160} {
+		err := Record(ctx, db, "span.trim", Target{SpanIDs: []int64{1}}, func(q *store.Queries) (Target, error) {
+			_, err := spanedit.Trim(ctx, q, 1, 100, end)
+			return Target{}, err
+		})
+		if err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if _, err := Undo(ctx, db, latestEntryID(t, db)-1); !errors.Is(err, ErrChanged) {
+		t.Errorf("Undo() of the first trim error = %v, want %v", err, ErrChanged)
+	}
+}
+
+func TestUndoOpenSpan(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	err := Record(ctx, db, "span.trim", Target{SpanIDs: []int64{2}}, func(q *store.Queries) (Target, error) {
+		_, err := spanedit.Trim(ctx, q, 2, 320, 400)
+		return Target{}, err
+	})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	// the end is unchanged, so the tracker keeps extending the latest span and attaching context to it
+	if _, err := db.UpdateSpan(ctx, store.UpdateSpanParams{ID: 2, EndAt: 450}); err != nil {
+		t.Fatalf("UpdateSpan() error = %v", err)
+	}
+	if err := db.UpsertSpanAttribute(ctx, store.UpsertSpanAttributeParams{SpanID: 2, Key: "channel", Value: "general", Type: "string"}); err != nil {
+		t.Fatalf("UpsertSpanAttribute() error = %v", err)
+	}
+	if err := db.UpsertSpanBrowserTab(ctx, store.UpsertSpanBrowserTabParams{SpanID: 2, Url: "https://app.slack.com", Domain: "app.slack.com"}); err != nil {
+		t.Fatalf("UpsertSpanBrowserTab() error = %v", err)
+	}
+
+	if _, err := Undo(ctx, db, latestEntryID(t, db)); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	span, err := db.SelectSpan(ctx, 2)
+	if err != nil {
+		t.Fatalf("SelectSpan() error = %v", err)
+	}
+	if span.StartAt != 300 || span.EndAt != 450 {
+		t.Errorf("span after undo = %d-%d, want 300-450", span.StartAt, span.EndAt)
+	}
+	attrs, err := db.SelectSpanAttributesBySpan(ctx, 2)
+	if err != nil {
+		t.Fatalf("SelectSpanAttributesBySpan() error = %v", err)
+	}
+	if len(attrs) != 1 || attrs[0].Value != "general" {
+		t.Errorf("attributes after undo = %+v, want channel=general", attrs)
+	}
+	if _, err := db.SelectSpanBrowserTab(ctx, 2); err != nil {
+		t.Errorf("SelectSpanBrowserTab() after undo error = %v", err)
+	}
+}
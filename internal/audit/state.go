@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/spanedit"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Target holds the ids of the rows a change affects.
+type Target struct {
+	ProjectIDs      []int64 `json:"project_ids,omitempty"`
+	CategoryIDs     []int64 `json:"category_ids,omitempty"`
+	ProjectRuleIDs  []int64 `json:"project_rule_ids,omitempty"`
+	CategoryRuleIDs []int64 `json:"category_rule_ids,omitempty"`
+	SpanIDs         []int64 `json:"span_ids,omitempty"`
+	ManualEntryIDs  []int64 `json:"manual_entry_ids,omitempty"`
+	// OpenSpanID is the span among SpanIDs the tracker was still extending after the change, see followOpenSpan.
+	OpenSpanID int64 `json:"open_span_id,omitempty"`
+}
+
+func (t Target) add(other Target) Target {
+	t.ProjectIDs = append(t.ProjectIDs, other.ProjectIDs...)
+	t.CategoryIDs = append(t.CategoryIDs, other.CategoryIDs...)
+	t.ProjectRuleIDs = append(t.ProjectRuleIDs, other.ProjectRuleIDs...)
+	t.CategoryRuleIDs = append(t.CategoryRuleIDs, other.CategoryRuleIDs...)
+	t.SpanIDs = append(t.SpanIDs, other.SpanIDs...)
+	t.ManualEntryIDs = append(t.ManualEntryIDs, other.ManualEntryIDs...)
+	return t
+}
+
+// State holds the rows of a Target at some point, rows that didn't exist are left out.
+type State struct {
+	Projects      []store.Project      `json:"projects,omitempty"`
+	Categories    []store.Category     `json:"categories,omitempty"`
+	ProjectRules  []store.ProjectRule  `json:"project_rules,omitempty"`
+	CategoryRules []store.CategoryRule `json:"category_rules,omitempty"`
+	Spans         []Span               `json:"spans,omitempty"`
+	ManualEntries []store.ManualEntry  `json:"manual_entries,omitempty"`
+}
+
+// Span is a span with the rows referring to it.
+type Span struct {
+	Span       store.Span             `json:"span"`
+	BrowserTab *store.SpanBrowserTab  `json:"browser_tab,omitempty"`
+	Terminal   *store.TerminalContext `json:"terminal,omitempty"`
+	Attributes []store.SpanAttribute  `json:"attributes,omitempty"`
+	Pin        *store.SpanPin         `json:"pin,omitempty"`
+}
+
+// capture returns the current rows of target, in the order of its ids.
+func capture(ctx context.Context, q *store.Queries, target Target) (State, error) {
+	var state State
+	for _, id := range target.ProjectIDs {
+		project, err := q.SelectProject(ctx, id)
+		if err := skipMissing(err, &state.Projects, project); err != nil {
+			return state, fmt.Errorf("select project: %w", err)
+		}
+	}
+	for _, id := range target.CategoryIDs {
+		category, err := q.SelectCategory(ctx, id)
+		if err := skipMissing(err, &state.Categories, category); err != nil {
+			return state, fmt.Errorf("select category: %w", err)
+		}
+	}
+	for _, id := range target.ProjectRuleIDs {
+		rule, err := q.SelectProjectRule(ctx, id)
+		if err := skipMissing(err, &state.ProjectRules, rule); err != nil {
+			return state, fmt.Errorf("select project rule: %w", err)
+		}
+	}
+	for _, id := range target.CategoryRuleIDs {
+		rule, err := q.SelectCategoryRule(ctx, id)
+		if err := skipMissing(err, &state.CategoryRules, rule); err != nil {
+			return state, fmt.Errorf("select category rule: %w", err)
+		}
+	}
+	for _, id := range target.SpanIDs {
+		span, ok, err := captureSpan(ctx, q, id)
+		if err != nil {
+			return state, err
+		}
+		if ok {
+			state.Spans = append(state.Spans, span)
+		}
+	}
+	for _, id := range target.ManualEntryIDs {
+		entry, err := q.SelectManualEntry(ctx, id)
+		if err := skipMissing(err, &state.ManualEntries, entry); err != nil {
+			return state, fmt.Errorf("select manual entry: %w", err)
+		}
+	}
+	return state, nil
+}
+
+// skipMissing appends row to rows if err is nil, and returns err unless it's sql.ErrNoRows.
+func skipMissing[T any](err error, rows *[]T, row T) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	*rows = append(*rows, row)
+	return nil
+}
+
+func captureSpan(ctx context.Context, q *store.Queries, id int64) (Span, bool, error) {
+	var span Span
+	var err error
+	span.Span, err = q.SelectSpan(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return span, false, nil
+	}
+	if err != nil {
+		return span, false, fmt.Errorf("select span: %w", err)
+	}
+
+	tab, err := q.SelectSpanBrowserTab(ctx, id)
+	if err == nil {
+		span.BrowserTab = &tab
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return span, false, fmt.Errorf("select span browser tab: %w", err)
+	}
+	terminal, err := q.SelectTerminalContext(ctx, id)
+	if err == nil {
+		span.Terminal = &terminal
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return span, false, fmt.Errorf("select terminal context: %w", err)
+	}
+	span.Attributes, err = q.SelectSpanAttributesBySpan(ctx, id)
+	if err != nil {
+		return span, false, fmt.Errorf("select span attributes: %w", err)
+	}
+	pin, err := q.SelectSpanPin(ctx, id)
+	if err == nil {
+		span.Pin = &pin
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return span, false, fmt.Errorf("select span pin: %w", err)
+	}
+	return span, true, nil
+}
+
+// restore replaces the rows of current with those of state. Restored spans must not overlap other spans or away
+// spans, see spanedit.CheckOverlap.
+func restore(ctx context.Context, q *store.Queries, current, state State) error {
+	for _, span := range current.Spans {
+		if err := spanedit.Delete(ctx, q, span.Span.ID); err != nil {
+			return err
+		}
+	}
+	for _, entry := range current.ManualEntries {
+		if err := q.DeleteManualEntry(ctx, entry.ID); err != nil {
+			return fmt.Errorf("delete manual entry: %w", err)
+		}
+	}
+	for _, rule := range current.ProjectRules {
+		if err := q.DeleteProjectRule(ctx, rule.ID); err != nil {
+			return fmt.Errorf("delete project rule: %w", err)
+		}
+	}
+	for _, rule := range current.CategoryRules {
+		if err := q.DeleteCategoryRule(ctx, rule.ID); err != nil {
+			return fmt.Errorf("delete category rule: %w", err)
+		}
+	}
+	for _, project := range current.Projects {
+		if err := q.DeleteProject(ctx, project.ID); err != nil {
+			return fmt.Errorf("delete project: %w", err)
+		}
+	}
+	for _, category := range current.Categories {
+		if err := q.DeleteCategory(ctx, category.ID); err != nil {
+			return fmt.Errorf("delete category: %w", err)
+		}
+	}
+
+	for _, project := range state.Projects {
+		if err := q.RestoreProject(ctx, store.RestoreProjectParams(project)); err != nil {
+			return fmt.Errorf("restore project: %w", err)
+		}
+	}
+	for _, category := range state.Categories {
+		if err := q.RestoreCategory(ctx, store.RestoreCategoryParams(category)); err != nil {
+			return fmt.Errorf("restore category: %w", err)
+		}
+	}
+	for _, rule := range state.ProjectRules {
+		if err := q.RestoreProjectRule(ctx, store.RestoreProjectRuleParams(rule)); err != nil {
+			return fmt.Errorf("restore project rule: %w", err)
+		}
+	}
+	for _, rule := range state.CategoryRules {
+		if err := q.RestoreCategoryRule(ctx, store.RestoreCategoryRuleParams(rule)); err != nil {
+			return fmt.Errorf("restore category rule: %w", err)
+		}
+	}
+	for _, span := range state.Spans {
+		if err := restoreSpan(ctx, q, span); err != nil {
+			return err
+		}
+	}
+	for _, entry := range state.ManualEntries {
+		if err := q.RestoreManualEntry(ctx, store.RestoreManualEntryParams(entry)); err != nil {
+			return fmt.Errorf("restore manual entry: %w", err)
+		}
+	}
+	return nil
+}
+
+func restoreSpan(ctx context.Context, q *store.Queries, span Span) error {
+	if err := spanedit.CheckOverlap(ctx, q, span.Span.StartAt, span.Span.EndAt); err != nil {
+		return fmt.Errorf("restore span %d: %w", span.Span.ID, err)
+	}
+	if err := q.RestoreSpan(ctx, store.RestoreSpanParams(span.Span)); err != nil {
+		return fmt.Errorf("restore span: %w", err)
+	}
+	if tab := span.BrowserTab; tab != nil {
+		if err := q.UpsertSpanBrowserTab(ctx, store.UpsertSpanBrowserTabParams(*tab)); err != nil {
+			return fmt.Errorf("upsert span browser tab: %w", err)
+		}
+	}
+	if terminal := span.Terminal; terminal != nil {
+		if err := q.UpsertTerminalContext(ctx, store.UpsertTerminalContextParams(*terminal)); err != nil {
+			return fmt.Errorf("upsert terminal context: %w", err)
+		}
+	}
+	for _, attr := range span.Attributes {
+		if err := q.UpsertSpanAttribute(ctx, store.UpsertSpanAttributeParams(attr)); err != nil {
+			return fmt.Errorf("upsert span attribute: %w", err)
+		}
+	}
+	if pin := span.Pin; pin != nil {
+		if err := q.UpsertSpanPin(ctx, store.UpsertSpanPinParams(*pin)); err != nil {
+			return fmt.Errorf("upsert span pin: %w", err)
+		}
+	}
+	return nil
+}
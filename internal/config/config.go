@@ -0,0 +1,247 @@
+// Package config loads the optional JSON config file (~/.mac-time-tracker/config.json).
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Config holds the user settings. Fields missing from the file keep their Default value.
+type Config struct {
+	PollInterval   Duration `json:"poll_interval"`
+	IdleThreshold  Duration `json:"idle_threshold"`
+	StaleThreshold Duration `json:"stale_threshold"`
+	// MinGap is the shortest away period the user is asked about when they return, "0s" disables it
+	MinGap Duration `json:"min_gap"`
+	// WebAddr is the address the web UI listens on, e.g. "127.0.0.1:8080"
+	WebAddr string `json:"web_addr"`
+	// DataDir holds the database and logs. A leading "~/" is expanded to the home directory.
+	DataDir  string  `json:"data_dir"`
+	LogLevel string  `json:"log_level"` // debug | info | warn | error
+	Privacy  Privacy `json:"privacy"`
+	Titles   Titles  `json:"titles"`
+}
+
+// Privacy controls what is stored about the focused window and the context from integrations.
+type Privacy struct {
+	StoreBrowserURLs      bool `json:"store_browser_urls"`      // otherwise only the domain of browser tabs is kept
+	StoreTerminalCommands bool `json:"store_terminal_commands"` // otherwise the command running in terminals is dropped
+	// DetectPrivateWindows skips private browsing and incognito windows, otherwise their title is redacted
+	DetectPrivateWindows bool          `json:"detect_private_windows"`
+	Rules                []PrivacyRule `json:"rules"`
+}
+
+// PrivacyRule applies Action to windows whose app and title match, the first matching rule wins.
+type PrivacyRule struct {
+	App    string `json:"app,omitempty"`   // regex matched against the app name and app id, empty matches any app
+	Title  string `json:"title,omitempty"` // regex matched against the window title, empty matches any title
+	Action string `json:"action"`          // ignore | redact | capture (keeps the first capture group of title)
+}
+
+// Titles controls how window titles are normalized before spans are compared and stored.
+type Titles struct {
+	// Normalize strips unread counters, unsaved markers and spinners that would otherwise split spans
+	Normalize bool           `json:"normalize"`
+	Rewrites  []TitleRewrite `json:"rewrites"`
+	KeepRaw   bool           `json:"keep_raw"` // store the title before normalization with each span
+}
+
+// TitleRewrite replaces matches of Pattern in the window titles of apps matching App.
+type TitleRewrite struct {
+	App     string `json:"app,omitempty"` // regex matched against the app name and app id, empty matches any app
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"` // may refer to capture groups, e.g. "$1"
+}
+
+// Privacy rule actions
+const (
+	ActionIgnore  = "ignore"
+	ActionRedact  = "redact"
+	ActionCapture = "capture"
+)
+
+// Default returns the settings used without a config file, storing data in workDir.
+func Default(workDir string) Config {
+	return Config{
+		PollInterval:   Duration(10 * time.Second),
+		IdleThreshold:  Duration(5 * time.Minute),
+		StaleThreshold: Duration(10 * time.Minute),
+		MinGap:         Duration(15 * time.Minute),
+		WebAddr:        ":8080",
+		DataDir:        workDir,
+		LogLevel:       "debug",
+		Privacy: Privacy{
+			StoreBrowserURLs:      true,
+			StoreTerminalCommands: true,
+			DetectPrivateWindows:  true,
+			Rules:                 []PrivacyRule{},
+		},
+		Titles: Titles{
+			Normalize: true,
+			Rewrites:  []TitleRewrite{},
+		},
+	}
+}
+
+// Load reads and validates the config file at path. A missing file is not an error, Default(workDir) is returned.
+func Load(path, workDir string) (Config, error) {
+	cfg := Default(workDir)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read config: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields() // catch typos instead of silently ignoring a setting
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	if strings.HasPrefix(cfg.DataDir, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return cfg, fmt.Errorf("expand data dir: %w", err)
+		}
+		cfg.DataDir = filepath.Join(homeDir, cfg.DataDir[2:])
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that the settings are usable together.
+func (c Config) Validate() error {
+	var errs []error
+	if c.PollInterval.D() < time.Second {
+		errs = append(errs, fmt.Errorf("poll_interval must be at least 1s, got %s", c.PollInterval))
+	}
+	if c.IdleThreshold.D() <= 0 {
+		errs = append(errs, fmt.Errorf("idle_threshold must be positive, got %s", c.IdleThreshold))
+	}
+	// otherwise every poll would start a new span
+	if c.StaleThreshold.D() <= c.PollInterval.D() {
+		errs = append(errs, fmt.Errorf("stale_threshold (%s) must be longer than poll_interval (%s)",
+			c.StaleThreshold, c.PollInterval))
+	}
+	if c.MinGap.D() < 0 {
+		errs = append(errs, fmt.Errorf("min_gap must not be negative, got %s", c.MinGap))
+	}
+	if _, _, err := net.SplitHostPort(c.WebAddr); err != nil {
+		errs = append(errs, fmt.Errorf("web_addr: %w", err))
+	}
+	if !filepath.IsAbs(c.DataDir) {
+		errs = append(errs, fmt.Errorf("data_dir must be an absolute path, got %q", c.DataDir))
+	}
+	if _, err := c.Level(); err != nil {
+		errs = append(errs, err)
+	}
+	for i, rule := range c.Privacy.Rules {
+		if err := rule.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("privacy rule %d: %w", i+1, err))
+		}
+	}
+	for i, rewrite := range c.Titles.Rewrites {
+		if err := rewrite.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("title rewrite %d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r TitleRewrite) validate() error {
+	if r.Pattern == "" {
+		return errors.New("needs a pattern")
+	}
+	if _, err := regexp.Compile(r.App); err != nil {
+		return fmt.Errorf("app: %w", err)
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("pattern: %w", err)
+	}
+	return nil
+}
+
+func (r PrivacyRule) validate() error {
+	if r.App == "" && r.Title == "" {
+		return errors.New("needs an app or title pattern")
+	}
+	if _, err := regexp.Compile(r.App); err != nil {
+		return fmt.Errorf("app: %w", err)
+	}
+	title, err := regexp.Compile(r.Title)
+	if err != nil {
+		return fmt.Errorf("title: %w", err)
+	}
+
+	switch r.Action {
+	case ActionIgnore, ActionRedact:
+	case ActionCapture:
+		if title.NumSubexp() == 0 {
+			return errors.New("capture needs a title pattern with a capture group")
+		}
+	default:
+		return fmt.Errorf("action must be ignore, redact or capture, got %q", r.Action)
+	}
+	return nil
+}
+
+// Level returns LogLevel as a slog.Level.
+func (c Config) Level() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return 0, fmt.Errorf("log_level must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	return level, nil
+}
+
+// Write saves c to path as indented JSON.
+func (c Config) Write(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// Duration is a time.Duration written as a string in JSON, e.g. "5m".
+type Duration time.Duration
+
+// D returns d as a time.Duration.
+func (d Duration) D() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes data to config.json in a temporary directory and returns its path.
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	workDir := t.TempDir()
+	cfg, err := Load(filepath.Join(workDir, "config.json"), workDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default(workDir)) {
+		t.Errorf("Load() = %+v, want the defaults", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Default().Validate() error = %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("UserHomeDir() error = %v", err)
+	}
+	workDir := "/var/lib/mtt"
+
+	tests := []struct {
+		name    string
+		data    string
+		want    func(cfg *Config) // changes the defaults to the expected config
+		wantErr string
+	}{
+		{
+			name: "missing fields keep their default",
+			data: `{"idle_threshold": "2m", "log_level": "info"}`,
+			want: func(cfg *Config) {
+				cfg.IdleThreshold = Duration(2 * time.Minute)
+				cfg.LogLevel = "info"
+			},
+		},
+		{
+			name: "data dir in the home directory",
+			data: `{"data_dir": "~/tracker"}`,
+			want: func(cfg *Config) { cfg.DataDir = filepath.Join(homeDir, "tracker") },
+		},
+		{
+			name: "nested settings keep the defaults of their missing fields",
+			data: `{"privacy": {"detect_private_windows": false, "rules": [{"app": "Slack", "action": "redact"}]},
+				"titles": {"rewrites": [{"pattern": "^\\(\\d+\\) ", "replace": ""}]}}`,
+			want: func(cfg *Config) {
+				cfg.Privacy.DetectPrivateWindows = false
+				cfg.Privacy.Rules = []PrivacyRule{{App: "Slack", Action: ActionRedact}}
+				cfg.Titles.Rewrites = []TitleRewrite{{Pattern: `^\(\d+\) `}}
+			},
+		},
+		{name: "unknown field", data: `{"idle_treshold": "2m"}`, wantErr: "unknown field"},
+		{name: "not json", data: `idle_threshold = "2m"`, wantErr: "parse config"},
+		{name: "bad duration", data: `{"poll_interval": "often"}`, wantErr: "parse config"},
+		{name: "poll interval too short", data: `{"poll_interval": "500ms"}`, wantErr: "poll_interval must be at least 1s"},
+		{name: "idle threshold not positive", data: `{"idle_threshold": "0s"}`, wantErr: "idle_threshold must be positive"},
+		{name: "stale threshold not after polls", data: `{"poll_interval": "1m", "stale_threshold": "1m"}`, wantErr: "stale_threshold (1m0s) must be longer"},
+		{name: "negative min gap", data: `{"min_gap": "-1m"}`, wantErr: "min_gap must not be negative"},
+		{name: "web addr without port", data: `{"web_addr": "localhost"}`, wantErr: "web_addr"},
+		{name: "relative data dir", data: `{"data_dir": "tracker"}`, wantErr: "data_dir must be an absolute path"},
+		{name: "unknown log level", data: `{"log_level": "verbose"}`, wantErr: "log_level must be"},
+		{name: "privacy rule without patterns", data: `{"privacy": {"rules": [{"action": "ignore"}]}}`, wantErr: "privacy rule 1: needs an app or title pattern"},
+		{name: "privacy rule with a bad regex", data: `{"privacy": {"rules": [{"app": "(", "action": "ignore"}]}}`, wantErr: "privacy rule 1: app"},
+		{name: "capture without a group", data: `{"privacy": {"rules": [{"title": "secret", "action": "capture"}]}}`, wantErr: "capture needs a title pattern with a capture group"},
+		{name: "unknown privacy action", data: `{"privacy": {"rules": [{"app": "Slack", "action": "hide"}]}}`, wantErr: "action must be ignore, redact or capture"},
+		{name: "title rewrite without a pattern", data: `{"titles": {"rewrites": [{"app": "Slack"}]}}`, wantErr: "title rewrite 1: needs a pattern"},
+		{name: "title rewrite with a bad regex", data: `{"titles": {"rewrites": [{"pattern": "["}]}}`, wantErr: "title rewrite 1: pattern"},
+		{
+			name:    "all errors are reported",
+			data:    `{"idle_threshold": "0s", "log_level": "verbose"}`,
+			wantErr: "idle_threshold must be positive, got 0s\nlog_level must be",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, tt.data), workDir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			want := Default(workDir)
+			tt.want(&want)
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("Load() =\n%+v\nwant\n%+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestWriteLoadsBack(t *testing.T) {
+	workDir := t.TempDir()
+	path := filepath.Join(workDir, "config.json")
+
+	cfg := Default(workDir)
+	cfg.MinGap = Duration(0)
+	cfg.Titles.Rewrites = []TitleRewrite{{App: "Code", Pattern: ` - Visual Studio Code$`}}
+	if err := cfg.Write(path); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got, err := Load(path, workDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("Load() =\n%+v\nwant\n%+v", got, cfg)
+	}
+}
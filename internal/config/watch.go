@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Watcher reloads the config file when it changes or the process receives SIGHUP. An invalid file is logged and the
+// previous config stays in effect.
+type Watcher struct {
+	path    string
+	workDir string
+	apply   func(Config)
+
+	mu      sync.Mutex
+	current Config
+	modTime time.Time
+}
+
+// NewWatcher creates a Watcher for the file at path, which current was loaded from. apply is called with every
+// valid config loaded after that.
+func NewWatcher(path, workDir string, current Config, apply func(Config)) *Watcher {
+	w := &Watcher{
+		path:    path,
+		workDir: workDir,
+		apply:   apply,
+		current: current,
+	}
+	w.modTime, _ = w.stat()
+	return w
+}
+
+// Current returns the config in effect.
+func (w *Watcher) Current() Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Reload loads the config file and applies it if it is valid.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.modTime, _ = w.stat()
+	cfg, err := Load(w.path, w.workDir)
+	if err != nil {
+		return err
+	}
+
+	// these are only read at startup
+	if cfg.DataDir != w.current.DataDir {
+		slog.Warn("Config data_dir changed, restart the daemon to apply it", "data_dir", cfg.DataDir)
+	}
+	if cfg.WebAddr != w.current.WebAddr {
+		slog.Info("Config web_addr changed, it applies the next time the web UI is opened", "web_addr", cfg.WebAddr)
+	}
+
+	w.current = cfg
+	w.apply(cfg)
+	slog.Info("Config reloaded", "path", w.path)
+	return nil
+}
+
+// Run checks the file for changes every interval and reloads on SIGHUP until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hup:
+			slog.Info("Received SIGHUP, reloading config")
+		case <-ticker.C:
+			modTime, err := w.stat()
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				slog.Warn("Failed to check config file", "error", err)
+				continue
+			}
+			w.mu.Lock()
+			changed := !modTime.Equal(w.modTime)
+			w.mu.Unlock()
+			if !changed {
+				continue
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		if err := w.Reload(); err != nil {
+			slog.Error("Failed to reload config, keeping the previous one", "error", err)
+		}
+	}
+}
+
+// stat returns the modification time of the file, zero if it doesn't exist.
+func (w *Watcher) stat() (time.Time, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatcherReload(t *testing.T) {
+	workDir := t.TempDir()
+	path := writeConfig(t, `{"log_level": "info"}`)
+	current, err := Load(path, workDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var applied []Config
+	w := NewWatcher(path, workDir, current, func(cfg Config) { applied = append(applied, cfg) })
+
+	// a valid change is applied
+	if err := os.WriteFile(path, []byte(`{"log_level": "warn"}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(applied) != 1 || applied[0].LogLevel != "warn" || w.Current().LogLevel != "warn" {
+		t.Fatalf("after Reload() applied %+v, current log level %q, want warn", applied, w.Current().LogLevel)
+	}
+
+	// an invalid file keeps the previous config
+	if err := os.WriteFile(path, []byte(`{"log_level": "verbose"}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Fatalf("Reload() of an invalid config error = nil")
+	}
+	if len(applied) != 1 || w.Current().LogLevel != "warn" {
+		t.Errorf("after a failed Reload() applied %d configs, current log level %q, want 1 and warn", len(applied), w.Current().LogLevel)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	workDir := t.TempDir()
+	path := writeConfig(t, `{"idle_threshold": "5m"}`)
+	current, err := Load(path, workDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	applied := make(chan Config, 1)
+	w := NewWatcher(path, workDir, current, func(cfg Config) { applied <- cfg })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// unchanged files aren't reloaded
+	select {
+	case cfg := <-applied:
+		t.Fatalf("applied %+v without a change", cfg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(path, []byte(`{"idle_threshold": "2m"}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	// the write may land within the resolution of the previous modification time
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	select {
+	case cfg := <-applied:
+		if cfg.IdleThreshold.D() != 2*time.Minute {
+			t.Errorf("applied idle_threshold %s, want 2m", cfg.IdleThreshold)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("changed config not applied")
+	}
+}
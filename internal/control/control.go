@@ -0,0 +1,161 @@
+// Package control serves the daemon control socket, which the status, pause, resume and reload commands talk to.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/tracker"
+	"github.com/fritzkeyzer/mac-time-tracker/pkg/unixsock"
+)
+
+const socketTimeout = 5 * time.Second
+
+// Commands accepted on the control socket
+const (
+	CommandStatus = "status"
+	CommandPause  = "pause"
+	CommandResume = "resume"
+	CommandReload = "reload"
+)
+
+// Request is a single JSON object sent per connection.
+type Request struct {
+	Command string `json:"command"`
+	// Duration is a time.ParseDuration string for pause, empty pauses until resumed.
+	Duration string `json:"duration,omitempty"`
+}
+
+// Response answers a Request.
+type Response struct {
+	OK     bool            `json:"ok"`
+	Error  string          `json:"error,omitempty"`
+	Status *tracker.Status `json:"status,omitempty"`
+}
+
+// Server handles control requests for a running tracker.
+type Server struct {
+	tracker *tracker.Tracker
+	reload  func() error
+}
+
+// NewServer creates a control Server for t. reload is called by the reload command and may be nil when there is
+// nothing to reload.
+func NewServer(t *tracker.Tracker, reload func() error) *Server {
+	return &Server{
+		tracker: t,
+		reload:  reload,
+	}
+}
+
+// Serve accepts control requests on the unix socket at socketPath until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, socketPath string) error {
+	ln, err := unixsock.Listen(ctx, socketPath)
+	if err != nil {
+		return fmt.Errorf("listen on control socket: %w", err)
+	}
+	defer ln.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer stop()
+
+	slog.Info("Listening for control commands", "socket", socketPath)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accept control connection: %w", err)
+		}
+		go s.handleConn(ctx, conn)
+	}
+}
+
+func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(socketTimeout))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		slog.Warn("Invalid control request", "error", err)
+		return
+	}
+
+	resp := Response{OK: true}
+	if err := s.handle(ctx, req, &resp); err != nil {
+		resp = Response{Error: err.Error()}
+	}
+
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		slog.Warn("Failed to write control response", "command", req.Command, "error", err)
+	}
+}
+
+func (s *Server) handle(ctx context.Context, req Request, resp *Response) error {
+	slog.Debug("Control request", "command", req.Command, "duration", req.Duration)
+
+	switch req.Command {
+	case CommandStatus:
+		status, err := s.tracker.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("get status: %w", err)
+		}
+		resp.Status = &status
+	case CommandPause:
+		var d time.Duration
+		if req.Duration != "" {
+			var err error
+			d, err = time.ParseDuration(req.Duration)
+			if err != nil {
+				return fmt.Errorf("invalid duration: %w", err)
+			}
+			if d <= 0 {
+				return fmt.Errorf("duration must be positive: %s", req.Duration)
+			}
+		}
+		s.tracker.Pause(d)
+	case CommandResume:
+		s.tracker.Resume()
+	case CommandReload:
+		if s.reload == nil {
+			return errors.New("nothing to reload")
+		}
+		if err := s.reload(); err != nil {
+			return fmt.Errorf("reload: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown command: %q", req.Command)
+	}
+	return nil
+}
+
+// Call sends req to the daemon listening on socketPath and returns its response. A response with an error is
+// returned as an error.
+func Call(socketPath string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("unix", socketPath, socketTimeout)
+	if err != nil {
+		return Response{}, fmt.Errorf("dial control socket (is the daemon running?): %w", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(socketTimeout))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("send request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("read response: %w", err)
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
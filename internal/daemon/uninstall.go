@@ -9,7 +9,9 @@ import (
 	"time"
 )
 
-func UninstallLaunchAgent(removeData bool) error {
+// UninstallLaunchAgent stops the daemon and removes the app bundle and LaunchAgent. With removeData it also removes
+// ~/.mac-time-tracker and the database and logs in dataDir, the configured data_dir.
+func UninstallLaunchAgent(dataDir string, removeData bool) error {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("failed to get user home dir: %w", err)
@@ -21,6 +23,7 @@ func UninstallLaunchAgent(removeData bool) error {
 	plistPath := filepath.Join(homeDir, "Library/LaunchAgents/com.fritzkeyzer.mac-time-tracker.plist")
 	appDir := filepath.Join(homeDir, "Applications", "MacTimeTracker.app")
 	workDir := filepath.Join(homeDir, ".mac-time-tracker")
+	dataDir = filepath.Clean(dataDir)
 
 	// 1. Stop the service
 	fmt.Println("Stopping service...")
@@ -68,6 +71,9 @@ func UninstallLaunchAgent(removeData bool) error {
 	// 4. Optionally remove user data
 	if removeData {
 		fmt.Println("Removing user data...")
+		if dataDir != workDir {
+			removeDataDir(dataDir)
+		}
 		if _, err := os.Stat(workDir); err == nil {
 			if err := os.RemoveAll(workDir); err != nil {
 				fmt.Printf("Warning: failed to remove user data: %v\n", err)
@@ -79,8 +85,30 @@ func UninstallLaunchAgent(removeData bool) error {
 		}
 	} else {
 		fmt.Printf("User data preserved at: %s\n", workDir)
+		if dataDir != workDir {
+			fmt.Printf("User data preserved at: %s\n", dataDir)
+		}
 	}
 
 	fmt.Println("\nUninstall complete!")
 	return nil
 }
+
+// removeDataDir removes the database and logs from a data_dir outside ~/.mac-time-tracker. The directory itself is
+// only removed if nothing else is left in it, it may be shared with other files, e.g. a synced folder.
+func removeDataDir(dataDir string) {
+	for _, name := range []string{"tracker.sqlite", "tracker.sqlite-wal", "tracker.sqlite-shm", "logs"} {
+		path := filepath.Join(dataDir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			fmt.Printf("Warning: failed to remove %s: %v\n", path, err)
+		} else {
+			fmt.Printf("Removed: %s\n", path)
+		}
+	}
+	if err := os.Remove(dataDir); err == nil {
+		fmt.Printf("Removed: %s\n", dataDir)
+	}
+}
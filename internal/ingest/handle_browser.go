@@ -0,0 +1,29 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/tracker"
+)
+
+type BrowserTabRequest struct {
+	URL       string `json:"url"`
+	Domain    string `json:"domain"`
+	Title     string `json:"title"`
+	Incognito bool   `json:"incognito"`
+}
+
+func (s *Server) handleBrowserTab(ctx context.Context, in BrowserTabRequest) error {
+	if in.URL == "" {
+		return errors.New("url is required")
+	}
+
+	s.tracker.UpdateBrowserTab(tracker.BrowserTab{
+		URL:       in.URL,
+		Domain:    in.Domain,
+		Title:     in.Title,
+		Incognito: in.Incognito,
+	})
+	return nil
+}
@@ -0,0 +1,127 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Heartbeat is the subset of a WakaTime heartbeat that is stored.
+// See https://wakatime.com/developers#heartbeats
+type Heartbeat struct {
+	Entity    string  `json:"entity"`
+	Type      string  `json:"type"`
+	Category  string  `json:"category"`
+	Time      float64 `json:"time"` // Unix timestamp with fractional seconds
+	Project   string  `json:"project"`
+	Branch    string  `json:"branch"`
+	Language  string  `json:"language"`
+	IsWrite   bool    `json:"is_write"`
+	UserAgent string  `json:"user_agent"`
+}
+
+type heartbeatResult struct {
+	Data HeartbeatData `json:"data"`
+}
+
+// HeartbeatData echoes a saved heartbeat back to the plugin, as the WakaTime API does.
+type HeartbeatData struct {
+	ID       string  `json:"id"`
+	Entity   string  `json:"entity"`
+	Type     string  `json:"type"`
+	Category string  `json:"category"`
+	Time     float64 `json:"time"`
+}
+
+// handleHeartbeat stores a single heartbeat (POST /api/v1/users/current/heartbeats).
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	var hb Heartbeat
+	if err := json.NewDecoder(r.Body).Decode(&hb); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.saveHeartbeat(r.Context(), hb, r.UserAgent())
+	if err != nil {
+		slog.Error("Failed to save heartbeat", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, heartbeatResult{Data: data})
+}
+
+// handleHeartbeatsBulk stores a batch of heartbeats (POST /api/v1/users/current/heartbeats.bulk), which wakatime-cli
+// uses for everything it sends. Every heartbeat gets its own result so the plugin only retries the failed ones.
+func (s *Server) handleHeartbeatsBulk(w http.ResponseWriter, r *http.Request) {
+	var heartbeats []Heartbeat
+	if err := json.NewDecoder(r.Body).Decode(&heartbeats); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// each response is a [body, status] pair
+	responses := make([][2]any, 0, len(heartbeats))
+	for _, hb := range heartbeats {
+		data, err := s.saveHeartbeat(r.Context(), hb, r.UserAgent())
+		if err != nil {
+			slog.Error("Failed to save heartbeat", "error", err)
+			responses = append(responses, [2]any{map[string]string{"error": err.Error()}, http.StatusBadRequest})
+			continue
+		}
+		responses = append(responses, [2]any{heartbeatResult{Data: data}, http.StatusCreated})
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"responses": responses})
+}
+
+func (s *Server) saveHeartbeat(ctx context.Context, hb Heartbeat, userAgent string) (HeartbeatData, error) {
+	if hb.Entity == "" {
+		return HeartbeatData{}, errors.New("entity is required")
+	}
+	if hb.Type == "" {
+		hb.Type = "file"
+	}
+	if hb.Time == 0 {
+		hb.Time = float64(time.Now().Unix())
+	}
+	if hb.UserAgent == "" {
+		hb.UserAgent = userAgent
+	}
+
+	saved, err := s.db.InsertHeartbeat(ctx, store.InsertHeartbeatParams{
+		Time:      int64(hb.Time),
+		Entity:    hb.Entity,
+		Type:      hb.Type,
+		Category:  hb.Category,
+		Project:   hb.Project,
+		Branch:    hb.Branch,
+		Language:  hb.Language,
+		IsWrite:   hb.IsWrite,
+		UserAgent: hb.UserAgent,
+	})
+	if err != nil {
+		return HeartbeatData{}, fmt.Errorf("insert heartbeat: %w", err)
+	}
+
+	return HeartbeatData{
+		ID:       strconv.FormatInt(saved.ID, 10),
+		Entity:   saved.Entity,
+		Type:     saved.Type,
+		Category: saved.Category,
+		Time:     hb.Time,
+	}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,75 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/tracker"
+	"github.com/fritzkeyzer/mac-time-tracker/pkg/rest"
+)
+
+// Server accepts activity reported by local integrations (the browser extension, editor plugins) and hands it to the
+// tracker or the store. It runs inside the daemon and only listens on the loopback interface.
+type Server struct {
+	tracker *tracker.Tracker
+	db      *store.Queries
+	addr    string
+}
+
+func NewServer(t *tracker.Tracker, db *store.Queries, addr string) *Server {
+	return &Server{
+		tracker: t,
+		db:      db,
+		addr:    addr,
+	}
+}
+
+// Start serves until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+
+	// Browser Endpoints
+	mux.Handle("/api/browser/tab", rest.WrapJSONIn(s.handleBrowserTab))
+
+	// WakaTime compatible Endpoints, point editor plugins at http://127.0.0.1:<port>/api/v1
+	mux.HandleFunc("POST /api/v1/users/current/heartbeats", s.handleHeartbeat)
+	mux.HandleFunc("POST /api/v1/users/current/heartbeats.bulk", s.handleHeartbeatsBulk)
+
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           localOnly(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	stop := context.AfterFunc(ctx, func() { _ = srv.Close() })
+	defer stop()
+
+	slog.Info("Starting ingest server", "addr", s.addr)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// localOnly rejects requests made by web pages. Browsers attach an Origin header to cross-origin requests, so only
+// requests without one (local tools) or from a browser extension are accepted.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" &&
+			!strings.HasPrefix(origin, "chrome-extension://") &&
+			!strings.HasPrefix(origin, "moz-extension://") &&
+			!strings.HasPrefix(origin, "safari-web-extension://") {
+			http.Error(w, "forbidden origin", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
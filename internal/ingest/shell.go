@@ -0,0 +1,83 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/tracker"
+	"github.com/fritzkeyzer/mac-time-tracker/pkg/unixsock"
+)
+
+const shellSocketTimeout = time.Second
+
+// ShellEvent is sent by `mac-time-tracker shell-event`, which the prompt hook runs, as a single JSON object per
+// connection.
+type ShellEvent struct {
+	PID     int32  `json:"pid"`
+	Cwd     string `json:"cwd"`
+	GitRoot string `json:"git_root"`
+	Command string `json:"command"`
+}
+
+// ServeShell accepts shell events on the unix socket at socketPath until ctx is cancelled.
+func (s *Server) ServeShell(ctx context.Context, socketPath string) error {
+	ln, err := unixsock.Listen(ctx, socketPath)
+	if err != nil {
+		return fmt.Errorf("listen on shell socket: %w", err)
+	}
+	defer ln.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer stop()
+
+	slog.Info("Listening for shell events", "socket", socketPath)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accept shell connection: %w", err)
+		}
+		go s.handleShellConn(conn)
+	}
+}
+
+func (s *Server) handleShellConn(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(shellSocketTimeout))
+
+	var ev ShellEvent
+	if err := json.NewDecoder(conn).Decode(&ev); err != nil {
+		slog.Warn("Invalid shell event", "error", err)
+		return
+	}
+	if ev.PID <= 0 || ev.Cwd == "" {
+		slog.Warn("Incomplete shell event", "pid", ev.PID, "hasCwd", ev.Cwd != "")
+		return
+	}
+
+	s.tracker.UpdateShell(tracker.ShellEvent{
+		PID:     ev.PID,
+		Cwd:     ev.Cwd,
+		GitRoot: ev.GitRoot,
+		Command: ev.Command,
+	})
+}
+
+// SendShellEvent sends ev to the daemon listening on socketPath.
+func SendShellEvent(socketPath string, ev ShellEvent) error {
+	conn, err := net.DialTimeout("unix", socketPath, shellSocketTimeout)
+	if err != nil {
+		return fmt.Errorf("dial shell socket: %w", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(shellSocketTimeout))
+	return json.NewEncoder(conn).Encode(ev)
+}
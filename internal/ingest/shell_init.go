@@ -0,0 +1,85 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShellInit returns the prompt hook for shell (zsh, bash or fish). The hook runs `<exe> shell-event` in the background
+// before every command and whenever the prompt returns.
+func ShellInit(shell, exe string) (string, error) {
+	var hook string
+	switch shell {
+	case "zsh":
+		hook = zshHook
+	case "bash":
+		hook = bashHook
+	case "fish":
+		hook = fishHook
+	default:
+		return "", fmt.Errorf("unsupported shell %q, expected zsh, bash or fish", shell)
+	}
+	return strings.ReplaceAll(hook, "@EXE@", shellQuote(exe)), nil
+}
+
+// shellQuote quotes s for zsh, bash and fish, which all treat single quoted strings literally.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+const zshHook = `# mac-time-tracker shell integration, add to ~/.zshrc:
+#   eval "$(mac-time-tracker shell-init zsh)"
+__mtt_send() {
+  @EXE@ shell-event --pid $$ --cwd "$PWD" --command "$1" >/dev/null 2>&1 &!
+}
+__mtt_preexec() { __mtt_send "$1"; }
+__mtt_precmd() { __mtt_send ""; }
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __mtt_preexec
+add-zsh-hook precmd __mtt_precmd
+`
+
+const bashHook = `# mac-time-tracker shell integration, add to ~/.bashrc:
+#   eval "$(mac-time-tracker shell-init bash)"
+__mtt_send() {
+  (@EXE@ shell-event --pid $$ --cwd "$PWD" --command "$1" >/dev/null 2>&1 &)
+}
+__mtt_preexec() {
+  # the DEBUG trap also fires for completion functions and PROMPT_COMMAND, only report the first command line
+  [ -n "$COMP_LINE" ] && return
+  [ -z "$__mtt_at_prompt" ] && return
+  unset __mtt_at_prompt
+  __mtt_send "$BASH_COMMAND"
+}
+__mtt_precmd() {
+  __mtt_send ""
+  __mtt_at_prompt=1
+}
+__mtt_bp_preexec() { __mtt_send "$1"; }
+if [ -n "${bash_preexec_imported:-${__bp_imported:-}}" ]; then
+  # bash-preexec owns the DEBUG trap and PROMPT_COMMAND, register with it instead
+  [[ " ${preexec_functions[*]} " == *" __mtt_bp_preexec "* ]] || preexec_functions+=(__mtt_bp_preexec)
+  [[ " ${precmd_functions[*]} " == *" __mtt_precmd "* ]] || precmd_functions+=(__mtt_precmd)
+elif [[ "$(trap -p DEBUG)" != *__mtt_preexec* ]]; then
+  # run a DEBUG trap set before, e.g. by another tool, after ours
+  __mtt_trap_command() { __mtt_prev_trap=$3; }
+  eval "__mtt_trap_command $(trap -p DEBUG)"
+  unset -f __mtt_trap_command
+  trap '__mtt_preexec; eval "$__mtt_prev_trap"' DEBUG
+  PROMPT_COMMAND="${PROMPT_COMMAND:+${PROMPT_COMMAND%;};}__mtt_precmd"
+fi
+`
+
+const fishHook = `# mac-time-tracker shell integration, add to ~/.config/fish/config.fish:
+#   mac-time-tracker shell-init fish | source
+function __mtt_send
+  command @EXE@ shell-event --pid $fish_pid --cwd "$PWD" --command "$argv[1]" >/dev/null 2>&1 &
+  disown 2>/dev/null
+end
+function __mtt_preexec --on-event fish_preexec
+  __mtt_send "$argv[1]"
+end
+function __mtt_postexec --on-event fish_postexec
+  __mtt_send ""
+end
+`
@@ -0,0 +1,65 @@
+package spanedit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Pin pins spans to a project and/or category, which override those from rules, heartbeats and titles. A nil project
+// or category keeps what a span is already pinned to, or leaves it to the rules, so pinning a span again only replaces
+// the parts given.
+func Pin(ctx context.Context, db *store.Queries, spanIDs []int64, projectID, categoryID *int64) error {
+	if projectID == nil && categoryID == nil {
+		return errors.New("a project or category is required")
+	}
+	return db.InTx(ctx, func(q *store.Queries) error {
+		if projectID != nil {
+			projects, err := q.SelectProjects(ctx)
+			if err != nil {
+				return fmt.Errorf("select projects: %w", err)
+			}
+			if !slices.ContainsFunc(projects, func(p store.Project) bool { return p.ID == *projectID }) {
+				return fmt.Errorf("project %d not found", *projectID)
+			}
+		}
+		if categoryID != nil {
+			categories, err := q.SelectCategories(ctx)
+			if err != nil {
+				return fmt.Errorf("select categories: %w", err)
+			}
+			if !slices.ContainsFunc(categories, func(c store.Category) bool { return c.ID == *categoryID }) {
+				return fmt.Errorf("category %d not found", *categoryID)
+			}
+		}
+
+		for _, id := range spanIDs {
+			if _, err := selectSpan(ctx, q, id); err != nil {
+				return err
+			}
+			if err := q.UpsertSpanPin(ctx, store.UpsertSpanPinParams{
+				SpanID:     id,
+				ProjectID:  projectID,
+				CategoryID: categoryID,
+			}); err != nil {
+				return fmt.Errorf("upsert span pin: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Unpin removes the pins of spans, handing them back to the rules.
+func Unpin(ctx context.Context, db *store.Queries, spanIDs []int64) error {
+	return db.InTx(ctx, func(q *store.Queries) error {
+		for _, id := range spanIDs {
+			if err := q.DeleteSpanPin(ctx, id); err != nil {
+				return fmt.Errorf("delete span pin: %w", err)
+			}
+		}
+		return nil
+	})
+}
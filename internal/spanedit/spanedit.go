@@ -0,0 +1,212 @@
+// Package spanedit fixes recorded spans for timesheets: splitting, merging, trimming and deleting them. Every edit runs
+// in a transaction and keeps spans from overlapping each other or away spans (see 001_init.sql).
+package spanedit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// ErrOverlap is returned for edits that would make spans overlap.
+var ErrOverlap = errors.New("spans would overlap")
+
+// Split ends the span at at and continues it in a new span until its original end. The new span gets a copy of the
+// span's browser tab, terminal context, attributes and pin. It returns both parts.
+func Split(ctx context.Context, db *store.Queries, id, at int64) (first, second store.Span, err error) {
+	err = db.InTx(ctx, func(q *store.Queries) error {
+		span, err := selectSpan(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		if at <= span.StartAt || at >= span.EndAt {
+			return errors.New("split time must be within the span")
+		}
+
+		first, err = q.UpdateSpanBounds(ctx, store.UpdateSpanBoundsParams{
+			StartAt: span.StartAt,
+			EndAt:   at,
+			ID:      span.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("update span: %w", err)
+		}
+		second, err = q.InsertSpan(ctx, store.InsertSpanParams{
+			AppName:        span.AppName,
+			WindowTitle:    span.WindowTitle,
+			StartAt:        at,
+			EndAt:          span.EndAt,
+			AppID:          span.AppID,
+			Pid:            span.Pid,
+			Display:        span.Display,
+			RawWindowTitle: span.RawWindowTitle,
+		})
+		if err != nil {
+			return fmt.Errorf("insert span: %w", err)
+		}
+		return copyContext(ctx, q, span.ID, second.ID)
+	})
+	return first, second, err
+}
+
+// Merge extends the earlier of two spans to the end of the later one, which is deleted. Nothing may lie between them.
+// The merged span keeps the app and title of the earlier span, and context of the later span it doesn't have yet.
+func Merge(ctx context.Context, db *store.Queries, id, otherID int64) (store.Span, error) {
+	var merged store.Span
+	err := db.InTx(ctx, func(q *store.Queries) error {
+		first, err := selectSpan(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		second, err := selectSpan(ctx, q, otherID)
+		if err != nil {
+			return err
+		}
+		if first.ID == second.ID {
+			return errors.New("can't merge a span with itself")
+		}
+		if second.StartAt < first.StartAt {
+			first, second = second, first
+		}
+
+		if err := CheckOverlap(ctx, q, first.StartAt, second.EndAt, first.ID, second.ID); err != nil {
+			return err
+		}
+
+		merged, err = q.UpdateSpanBounds(ctx, store.UpdateSpanBoundsParams{
+			StartAt: first.StartAt,
+			EndAt:   max(first.EndAt, second.EndAt),
+			ID:      first.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("update span: %w", err)
+		}
+		if err := copyContext(ctx, q, second.ID, first.ID); err != nil {
+			return err
+		}
+		return deleteSpan(ctx, q, second.ID)
+	})
+	return merged, err
+}
+
+// Trim moves the start and end of a span. It may also grow the span, as long as it doesn't overlap other spans or away
+// spans.
+func Trim(ctx context.Context, db *store.Queries, id, startAt, endAt int64) (store.Span, error) {
+	var trimmed store.Span
+	err := db.InTx(ctx, func(q *store.Queries) error {
+		span, err := selectSpan(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		if startAt > endAt {
+			return errors.New("span must not end before it starts")
+		}
+		if err := CheckOverlap(ctx, q, startAt, endAt, span.ID); err != nil {
+			return err
+		}
+
+		trimmed, err = q.UpdateSpanBounds(ctx, store.UpdateSpanBoundsParams{
+			StartAt: startAt,
+			EndAt:   endAt,
+			ID:      span.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("update span: %w", err)
+		}
+		return nil
+	})
+	return trimmed, err
+}
+
+// Delete removes a span with its browser tab, terminal context, attributes and pin.
+func Delete(ctx context.Context, db *store.Queries, id int64) error {
+	return db.InTx(ctx, func(q *store.Queries) error {
+		span, err := selectSpan(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		return deleteSpan(ctx, q, span.ID)
+	})
+}
+
+func selectSpan(ctx context.Context, q *store.Queries, id int64) (store.Span, error) {
+	span, err := q.SelectSpan(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return span, fmt.Errorf("span %d not found", id)
+	}
+	if err != nil {
+		return span, fmt.Errorf("select span: %w", err)
+	}
+	return span, nil
+}
+
+// CheckOverlap returns ErrOverlap if a span other than the edited ones, or an away span, overlaps [startAt, endAt].
+// Spans that only touch the range, ending at startAt or starting at endAt, don't overlap it.
+func CheckOverlap(ctx context.Context, q *store.Queries, startAt, endAt int64, editedIDs ...int64) error {
+	spans, err := q.SelectSpansBetween(ctx, store.SelectSpansBetweenParams{
+		StartAt: startAt,
+		EndAt:   endAt,
+	})
+	if err != nil {
+		return fmt.Errorf("select spans: %w", err)
+	}
+	for _, span := range spans {
+		if !slices.Contains(editedIDs, span.ID) {
+			return fmt.Errorf("%w: span %d (%s)", ErrOverlap, span.ID, span.AppName)
+		}
+	}
+
+	aways, err := q.SelectAwaySpansBetween(ctx, store.SelectAwaySpansBetweenParams{
+		StartAt: startAt,
+		EndAt:   endAt,
+	})
+	if err != nil {
+		return fmt.Errorf("select away spans: %w", err)
+	}
+	if len(aways) > 0 {
+		return fmt.Errorf("%w: away span %d (%s)", ErrOverlap, aways[0].ID, aways[0].Kind)
+	}
+	return nil
+}
+
+// copyContext copies the browser tab, terminal context, attributes and pin of one span to another, keeping those the
+// other span already has.
+func copyContext(ctx context.Context, q *store.Queries, fromID, toID int64) error {
+	if err := q.CopySpanBrowserTab(ctx, store.CopySpanBrowserTabParams{ToSpanID: toID, FromSpanID: fromID}); err != nil {
+		return fmt.Errorf("copy span browser tab: %w", err)
+	}
+	if err := q.CopyTerminalContext(ctx, store.CopyTerminalContextParams{ToSpanID: toID, FromSpanID: fromID}); err != nil {
+		return fmt.Errorf("copy terminal context: %w", err)
+	}
+	if err := q.CopySpanAttributes(ctx, store.CopySpanAttributesParams{ToSpanID: toID, FromSpanID: fromID}); err != nil {
+		return fmt.Errorf("copy span attributes: %w", err)
+	}
+	if err := q.CopySpanPin(ctx, store.CopySpanPinParams{ToSpanID: toID, FromSpanID: fromID}); err != nil {
+		return fmt.Errorf("copy span pin: %w", err)
+	}
+	return nil
+}
+
+// deleteSpan deletes a span and the rows referring to it, foreign keys aren't enforced.
+func deleteSpan(ctx context.Context, q *store.Queries, id int64) error {
+	if err := q.DeleteSpanBrowserTab(ctx, id); err != nil {
+		return fmt.Errorf("delete span browser tab: %w", err)
+	}
+	if err := q.DeleteTerminalContext(ctx, id); err != nil {
+		return fmt.Errorf("delete terminal context: %w", err)
+	}
+	if err := q.DeleteSpanAttributes(ctx, id); err != nil {
+		return fmt.Errorf("delete span attributes: %w", err)
+	}
+	if err := q.DeleteSpanPin(ctx, id); err != nil {
+		return fmt.Errorf("delete span pin: %w", err)
+	}
+	if err := q.DeleteSpan(ctx, id); err != nil {
+		return fmt.Errorf("delete span: %w", err)
+	}
+	return nil
+}
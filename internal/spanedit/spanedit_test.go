@@ -0,0 +1,254 @@
+package spanedit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// newTestDB returns a database with the spans Code 100-200 (1), Firefox 200-300 (2) and Slack 400-500 (3), and an
+// idle away span 300-350.
+func newTestDB(t *testing.T) *store.Queries {
+	t.Helper()
+	ctx := context.Background()
+	db, closeDB, err := store.InitDB(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatalf("InitDB() error = %v", err)
+	}
+	t.Cleanup(closeDB)
+
+	for _, span := range []store.InsertSpanParams{
+		{AppName: "Code", StartAt: 100, EndAt: 200},
+		{AppName: "Firefox", StartAt: 200, EndAt: 300},
+		{AppName: "Slack", StartAt: 400, EndAt: 500},
+	} {
+		if _, err := db.InsertSpan(ctx, span); err != nil {
+			t.Fatalf("InsertSpan() error = %v", err)
+		}
+	}
+	if _, err := db.InsertAwaySpan(ctx, store.InsertAwaySpanParams{Kind: "idle", StartAt: 300, EndAt: 350}); err != nil {
+		t.Fatalf("InsertAwaySpan() error = %v", err)
+	}
+	return db
+}
+
+// bounds is the app, start and end of a span.
+type bounds struct {
+	app        string
+	start, end int64
+}
+
+func TestEdits(t *testing.T) {
+	unchanged := []bounds{{"Code", 100, 200}, {"Firefox", 200, 300}, {"Slack", 400, 500}}
+
+	tests := []struct {
+		name        string
+		edit        func(ctx context.Context, db *store.Queries) error
+		wantErr     bool
+		wantOverlap bool
+		want        []bounds
+	}{
+		{
+			name: "split within the span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, _, err := Split(ctx, db, 1, 150)
+				return err
+			},
+			want: []bounds{{"Code", 100, 150}, {"Code", 150, 200}, {"Firefox", 200, 300}, {"Slack", 400, 500}},
+		},
+		{
+			name: "split at the start",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, _, err := Split(ctx, db, 1, 100)
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "split at the end",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, _, err := Split(ctx, db, 1, 200)
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "merge adjacent spans",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Merge(ctx, db, 2, 1)
+				return err
+			},
+			want: []bounds{{"Code", 100, 300}, {"Slack", 400, 500}},
+		},
+		{
+			name: "merge across a span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Merge(ctx, db, 1, 3)
+				return err
+			},
+			wantOverlap: true,
+		},
+		{
+			name: "merge across an away span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Merge(ctx, db, 2, 3)
+				return err
+			},
+			wantOverlap: true,
+		},
+		{
+			name: "merge with itself",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Merge(ctx, db, 1, 1)
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "trim inside the span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Trim(ctx, db, 2, 220, 280)
+				return err
+			},
+			want: []bounds{{"Code", 100, 200}, {"Firefox", 220, 280}, {"Slack", 400, 500}},
+		},
+		{
+			name: "grow into free time",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Trim(ctx, db, 1, 50, 200)
+				return err
+			},
+			want: []bounds{{"Code", 50, 200}, {"Firefox", 200, 300}, {"Slack", 400, 500}},
+		},
+		{
+			name: "grow to touch an away span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Trim(ctx, db, 3, 350, 500)
+				return err
+			},
+			want: []bounds{{"Code", 100, 200}, {"Firefox", 200, 300}, {"Slack", 350, 500}},
+		},
+		{
+			name: "grow over a span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Trim(ctx, db, 1, 100, 250)
+				return err
+			},
+			wantOverlap: true,
+		},
+		{
+			name: "grow over an away span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Trim(ctx, db, 3, 340, 500)
+				return err
+			},
+			wantOverlap: true,
+		},
+		{
+			name: "trim to end before the start",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				_, err := Trim(ctx, db, 1, 200, 100)
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "delete",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				return Delete(ctx, db, 2)
+			},
+			want: []bounds{{"Code", 100, 200}, {"Slack", 400, 500}},
+		},
+		{
+			name: "delete a missing span",
+			edit: func(ctx context.Context, db *store.Queries) error {
+				return Delete(ctx, db, 42)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+
+			err := tt.edit(ctx, db)
+			if (err != nil) != (tt.wantErr || tt.wantOverlap) {
+				t.Fatalf("edit error = %v, wantErr %v, wantOverlap %v", err, tt.wantErr, tt.wantOverlap)
+			}
+			if errors.Is(err, ErrOverlap) != tt.wantOverlap {
+				t.Fatalf("edit error = %v, wantOverlap %v", err, tt.wantOverlap)
+			}
+
+			want := tt.want
+			if err != nil {
+				want = unchanged // a failed edit changes nothing
+			}
+			spans, err := db.SelectSpansBetween(ctx, store.SelectSpansBetweenParams{StartAt: 0, EndAt: 1000})
+			if err != nil {
+				t.Fatalf("SelectSpansBetween() error = %v", err)
+			}
+			var got []bounds
+			for _, span := range spans {
+				got = append(got, bounds{span.AppName, span.StartAt, span.EndAt})
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("spans = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSplitCopiesContext(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if err := db.UpsertSpanAttribute(ctx, store.UpsertSpanAttributeParams{SpanID: 1, Key: "cwd", Value: "/src", Type: "string"}); err != nil {
+		t.Fatalf("UpsertSpanAttribute() error = %v", err)
+	}
+	_, second, err := Split(ctx, db, 1, 150)
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+
+	attrs, err := db.SelectSpanAttributesBySpan(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("SelectSpanAttributesBySpan() error = %v", err)
+	}
+	if len(attrs) != 1 || attrs[0].Key != "cwd" || attrs[0].Value != "/src" {
+		t.Errorf("attributes of the second part = %+v, want cwd=/src", attrs)
+	}
+}
+
+func TestPinKeepsOtherPart(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	project, err := db.InsertProject(ctx, store.InsertProjectParams{Name: "Acme", Color: "#ff0000"})
+	if err != nil {
+		t.Fatalf("InsertProject() error = %v", err)
+	}
+	category, err := db.InsertCategory(ctx, store.InsertCategoryParams{Name: "Dev", Color: "#00ff00"})
+	if err != nil {
+		t.Fatalf("InsertCategory() error = %v", err)
+	}
+
+	if err := Pin(ctx, db, []int64{1}, &project.ID, nil); err != nil {
+		t.Fatalf("Pin() project error = %v", err)
+	}
+	if err := Pin(ctx, db, []int64{1}, nil, &category.ID); err != nil {
+		t.Fatalf("Pin() category error = %v", err)
+	}
+
+	pin, err := db.SelectSpanPin(ctx, 1)
+	if err != nil {
+		t.Fatalf("SelectSpanPin() error = %v", err)
+	}
+	if pin.ProjectID == nil || *pin.ProjectID != project.ID || pin.CategoryID == nil || *pin.CategoryID != category.ID {
+		t.Errorf("pin = %+v, want project %d and category %d", pin, project.ID, category.ID)
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AttrFilter keeps the spans whose attribute Key compares to Value with Op: = and != (by the attribute's type, strings
+// ignoring case), ~ and !~ (regular expressions), >, >=, < and <= (numbers), or "" for spans that have the attribute.
+// Spans without the attribute pass != and !~.
+type AttrFilter struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// condition returns the SQL condition on a span for the filter, and its arguments.
+func (f AttrFilter) condition() (string, []any, error) {
+	const hasAttr = "exists (select 1 from span_attribute a where a.span_id = span.id and a.key = ?"
+
+	switch f.Op {
+	case "":
+		return hasAttr + ")", []any{f.Key}, nil
+	case "=", "!=":
+		// values that aren't a number or bool never equal attributes of that type
+		var number, boolean any
+		if n, err := strconv.ParseFloat(f.Value, 64); err == nil {
+			number = n
+		}
+		if b, err := strconv.ParseBool(f.Value); err == nil {
+			boolean = strconv.FormatBool(b)
+		}
+		cond := hasAttr + ` and case a.type
+			when 'number' then cast(a.value as real) = ?
+			when 'bool' then a.value = ?
+			else a.value = ? collate nocase end)`
+		if f.Op == "!=" {
+			cond = "not " + cond
+		}
+		return cond, []any{f.Key, number, boolean, f.Value}, nil
+	case "~":
+		return hasAttr + " and a.value regexp ?)", []any{f.Key, f.Value}, nil
+	case "!~":
+		return "not " + hasAttr + " and a.value regexp ?)", []any{f.Key, f.Value}, nil
+	case ">", ">=", "<", "<=":
+		n, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s needs a number", f.Op)
+		}
+		return hasAttr + " and a.type = 'number' and cast(a.value as real) " + f.Op + " ?)", []any{f.Key, n}, nil
+	}
+	return "", nil, fmt.Errorf("unknown operator %q", f.Op)
+}
+
+// SelectFilteredSpans is SelectSpans for the spans whose attributes pass all filters.
+func (q *Queries) SelectFilteredSpans(ctx context.Context, arg SelectSpansParams, filters []AttrFilter) ([]Span, error) {
+	query := strings.TrimSuffix(strings.TrimSpace(selectSpans), ";")
+	args := []any{arg.StartAt, arg.EndAt}
+	for _, f := range filters {
+		cond, condArgs, err := f.condition()
+		if err != nil {
+			return nil, fmt.Errorf("filter attr.%s: %w", f.Key, err)
+		}
+		query += "\n  and " + cond
+		args = append(args, condArgs...)
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Span
+	for rows.Next() {
+		var i Span
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppName,
+			&i.WindowTitle,
+			&i.StartAt,
+			&i.EndAt,
+			&i.AppID,
+			&i.Pid,
+			&i.Display,
+			&i.RawWindowTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// sqlRegexp implements the SQL `value regexp pattern` operator, which SQLite leaves to the application.
+func sqlRegexp(pattern, value string) (bool, error) {
+	re, err := compileRegexp(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(value), nil
+}
+
+// maxCachedRegexps bounds the patterns kept by compileRegexp, filters change rarely so starting over is enough.
+const maxCachedRegexps = 64
+
+// regexpCache holds the compiled patterns of sqlRegexp, which runs for every row a query checks.
+var regexpCache = struct {
+	sync.Mutex
+	byPattern map[string]*regexp.Regexp
+}{byPattern: make(map[string]*regexp.Regexp)}
+
+// compileRegexp compiles pattern, or returns it from the cache. It's safe for concurrent use.
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+	if re, ok := regexpCache.byPattern[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexpCache.byPattern) >= maxCachedRegexps {
+		clear(regexpCache.byPattern)
+	}
+	regexpCache.byPattern[pattern] = re
+	return re, nil
+}
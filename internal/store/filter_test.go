@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestSelectFilteredSpans(t *testing.T) {
+	ctx := context.Background()
+	db, closeDB, err := InitDB(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatalf("InitDB() error = %v", err)
+	}
+	defer closeDB()
+
+	// spans 1 to 3 with their attributes, span 3 has none
+	attrs := [][]UpsertSpanAttributeParams{
+		{{Key: "domain", Value: "GitHub.com", Type: "string"}, {Key: "incognito", Value: "false", Type: "bool"}},
+		{{Key: "domain", Value: "example.com", Type: "string"}, {Key: "incognito", Value: "true", Type: "bool"}, {Key: "lines", Value: "120", Type: "number"}},
+		nil,
+	}
+	for i, spanAttrs := range attrs {
+		span, err := db.InsertSpan(ctx, InsertSpanParams{AppName: "Firefox", StartAt: int64(100 * (i + 1)), EndAt: int64(100*(i+1) + 50)})
+		if err != nil {
+			t.Fatalf("InsertSpan() error = %v", err)
+		}
+		for _, attr := range spanAttrs {
+			attr.SpanID = span.ID
+			if err := db.UpsertSpanAttribute(ctx, attr); err != nil {
+				t.Fatalf("UpsertSpanAttribute() error = %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		filters []AttrFilter
+		want    []int64
+	}{
+		{"no filters", nil, []int64{1, 2, 3}},
+		{"has attribute", []AttrFilter{{Key: "lines"}}, []int64{2}},
+		{"string equal ignores case", []AttrFilter{{Key: "domain", Op: "=", Value: "github.com"}}, []int64{1}},
+		{"not equal includes spans without it", []AttrFilter{{Key: "domain", Op: "!=", Value: "github.com"}}, []int64{2, 3}},
+		{"bool equal", []AttrFilter{{Key: "incognito", Op: "=", Value: "TRUE"}}, []int64{2}},
+		{"bool against a word", []AttrFilter{{Key: "incognito", Op: "=", Value: "yes"}}, nil},
+		{"number equal", []AttrFilter{{Key: "lines", Op: "=", Value: "120.0"}}, []int64{2}},
+		{"regex", []AttrFilter{{Key: "domain", Op: "~", Value: `^git`}}, nil},
+		{"regex with flags", []AttrFilter{{Key: "domain", Op: "~", Value: `(?i)^git`}}, []int64{1}},
+		{"not regex", []AttrFilter{{Key: "domain", Op: "!~", Value: `\.com$`}}, []int64{3}},
+		{"greater than", []AttrFilter{{Key: "lines", Op: ">", Value: "100"}}, []int64{2}},
+		{"less or equal", []AttrFilter{{Key: "lines", Op: "<=", Value: "100"}}, nil},
+		{"all filters must match", []AttrFilter{{Key: "domain", Op: "~", Value: `\.com$`}, {Key: "incognito", Op: "=", Value: "false"}}, []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans, err := db.SelectFilteredSpans(ctx, SelectSpansParams{StartAt: 0, EndAt: 1000}, tt.filters)
+			if err != nil {
+				t.Fatalf("SelectFilteredSpans() error = %v", err)
+			}
+			var got []int64
+			for _, span := range spans {
+				got = append(got, span.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SelectFilteredSpans() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRegexp(t *testing.T) {
+	first, err := compileRegexp(`^github\.com$`)
+	if err != nil {
+		t.Fatalf("compileRegexp() error = %v", err)
+	}
+	if again, _ := compileRegexp(`^github\.com$`); again != first {
+		t.Errorf("compileRegexp() compiled a cached pattern again")
+	}
+	if _, err := compileRegexp(`(`); err == nil {
+		t.Errorf("compileRegexp() of an invalid pattern error = nil")
+	}
+
+	for i := range 2 * maxCachedRegexps {
+		if _, err := compileRegexp(strconv.Itoa(i)); err != nil {
+			t.Fatalf("compileRegexp() error = %v", err)
+		}
+	}
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+	if n := len(regexpCache.byPattern); n > maxCachedRegexps {
+		t.Errorf("cache holds %d patterns, want at most %d", n, maxCachedRegexps)
+	}
+}
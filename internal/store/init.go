@@ -9,9 +9,20 @@ import (
 	"sort"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// driverName is the sqlite3 driver with the functions the queries use on top of SQLite's own, see sqlRegexp.
+const driverName = "sqlite3_functions"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", sqlRegexp, true)
+		},
+	})
+}
+
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
@@ -19,7 +30,7 @@ var migrationsFS embed.FS
 func InitDB(dbFile string) (*Queries, func(), error) {
 	closeFn := func() {}
 
-	db, err := sql.Open("sqlite3", dbFile)
+	db, err := sql.Open(driverName, dbFile)
 	if err != nil {
 		return nil, closeFn, fmt.Errorf("open db: %w", err)
 	}
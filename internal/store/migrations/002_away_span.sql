@@ -0,0 +1,12 @@
+-- periods without tracked activity, sequential with (and never overlapping) span
+create table away_span
+(
+    id       integer primary key autoincrement,
+    kind     text    not null, -- idle | asleep | locked | away
+    reason   text    not null, -- why tracking stopped: idle_threshold | stale_gap | screen_locked | daemon_stopped
+    start_at integer not null, -- Unix timestamp
+    end_at   integer not null  -- Unix timestamp
+);
+
+create index idx_away_span_start_at on away_span (start_at);
+create index idx_away_span_end_at on away_span (end_at);
@@ -0,0 +1,4 @@
+-- stable identity of the focused app, so rules can tell apart apps sharing a display name
+alter table span add column app_id text not null default ''; -- bundle id (macOS), executable path or WM_CLASS (Linux)
+alter table span add column pid integer not null default 0;
+alter table span add column display text not null default ''; -- name of the display the window was on
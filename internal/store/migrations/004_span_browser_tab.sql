@@ -0,0 +1,10 @@
+-- the active browser tab reported by the browser extension while a span was recorded
+create table span_browser_tab
+(
+    span_id   integer primary key,
+    url       text    not null,
+    domain    text    not null,
+    title     text    not null,
+    incognito boolean not null default 0,
+    foreign key (span_id) references span (id) on delete cascade
+);
@@ -0,0 +1,17 @@
+-- editor activity received on the WakaTime compatible heartbeat endpoint
+create table heartbeat
+(
+    id         integer primary key autoincrement,
+    time       integer not null,            -- Unix timestamp
+    entity     text    not null,            -- file path, app name or domain
+    type       text    not null,            -- file | app | domain
+    category   text    not null default '', -- coding | debugging | building | ...
+    project    text    not null default '',
+    branch     text    not null default '',
+    language   text    not null default '',
+    is_write   boolean not null default 0,
+    user_agent text    not null default ''  -- identifies the editor plugin
+);
+
+-- plugins resend heartbeats that were queued while offline
+create unique index idx_heartbeat_time_entity on heartbeat (time, entity);
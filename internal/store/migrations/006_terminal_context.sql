@@ -0,0 +1,9 @@
+-- what the shell in the focused terminal was doing while a span was recorded
+create table terminal_context
+(
+    span_id  integer primary key,
+    cwd      text not null,
+    git_root text not null default '', -- top level of the git repository containing cwd
+    command  text not null default '', -- running command, empty while at the prompt
+    foreign key (span_id) references span (id) on delete cascade
+);
@@ -0,0 +1,2 @@
+-- where the terminal context came from: the shell prompt hook or the active pane of a tmux client
+alter table terminal_context add column source text not null default 'shell'; -- shell | tmux
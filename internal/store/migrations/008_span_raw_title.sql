@@ -0,0 +1,2 @@
+-- window title before normalization, only stored when enabled in the config ('' otherwise)
+alter table span add column raw_window_title text not null default '';
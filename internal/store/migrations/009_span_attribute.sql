@@ -0,0 +1,9 @@
+-- structured fields parsed from a span's window title (project, file, channel, document, meeting)
+create table span_attribute
+(
+    span_id integer not null,
+    key     text    not null,
+    value   text    not null,
+    primary key (span_id, key),
+    foreign key (span_id) references span (id) on delete cascade
+);
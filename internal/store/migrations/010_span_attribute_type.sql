@@ -0,0 +1,18 @@
+-- attributes are shared by all enrichment sources, values are stored as text and typed by `type`
+alter table span_attribute add column type text not null default 'string'; -- string | number | bool
+
+create index span_attribute_key_value on span_attribute (key, value);
+
+-- copy the context recorded before attributes existed
+insert or ignore into span_attribute(span_id, key, value, type)
+select span_id, 'url', url, 'string' from span_browser_tab where url != '';
+insert or ignore into span_attribute(span_id, key, value, type)
+select span_id, 'domain', domain, 'string' from span_browser_tab where domain != '';
+insert or ignore into span_attribute(span_id, key, value, type)
+select span_id, 'incognito', case when incognito then 'true' else 'false' end, 'bool' from span_browser_tab;
+insert or ignore into span_attribute(span_id, key, value, type)
+select span_id, 'cwd', cwd, 'string' from terminal_context;
+insert or ignore into span_attribute(span_id, key, value, type)
+select span_id, 'git_root', git_root, 'string' from terminal_context where git_root != '';
+insert or ignore into span_attribute(span_id, key, value, type)
+select span_id, 'command', command, 'string' from terminal_context;
@@ -0,0 +1,14 @@
+-- time recorded with a manual timer, takes precedence over the spans and away spans it overlaps
+create table manual_entry
+(
+    id         integer primary key autoincrement,
+    project_id integer not null,
+    note       text    not null default '',
+    start_at   integer not null,           -- Unix timestamp
+    end_at     integer not null default 0, -- Unix timestamp, 0 while the timer is running
+    foreign key (project_id) references project (id) on delete cascade
+);
+
+create index idx_manual_entry_start_at on manual_entry (start_at);
+-- only one timer runs at a time
+create unique index idx_manual_entry_running on manual_entry (end_at) where end_at = 0;
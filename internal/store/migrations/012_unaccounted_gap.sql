@@ -0,0 +1,36 @@
+-- manual entries can also be breaks and meetings, which have no project
+create table manual_entry_new
+(
+    id         integer primary key autoincrement,
+    project_id integer,                            -- set for kind project
+    note       text    not null default '',
+    start_at   integer not null,                   -- Unix timestamp
+    end_at     integer not null default 0,         -- Unix timestamp, 0 while the timer is running
+    kind       text    not null default 'project', -- project | break | meeting
+    foreign key (project_id) references project (id) on delete cascade
+);
+
+insert into manual_entry_new (id, project_id, note, start_at, end_at)
+select id, project_id, note, start_at, end_at
+from manual_entry;
+
+drop table manual_entry;
+alter table manual_entry_new rename to manual_entry;
+
+create index idx_manual_entry_start_at on manual_entry (start_at);
+-- only one timer runs at a time
+create unique index idx_manual_entry_running on manual_entry (end_at) where end_at = 0;
+
+-- away periods recorded when the user returns, until they say what they were doing
+create table unaccounted_gap
+(
+    id              integer primary key autoincrement,
+    start_at        integer not null,            -- Unix timestamp
+    end_at          integer not null,            -- Unix timestamp
+    kind            text    not null,            -- kind of the away span the gap ended with: idle | asleep | locked | away
+    resolution      text    not null default '', -- empty while pending | project | break | meeting | discard
+    manual_entry_id integer,                     -- the entry recorded for the gap, unless discarded
+    foreign key (manual_entry_id) references manual_entry (id) on delete set null
+);
+
+create index idx_unaccounted_gap_resolution on unaccounted_gap (resolution);
@@ -0,0 +1,10 @@
+-- project and category pinned to a span by hand, overriding the rules
+create table span_pin
+(
+    span_id     integer primary key,
+    project_id  integer, -- null to keep the project from the rules
+    category_id integer, -- null to keep the category from the rules
+    foreign key (span_id) references span (id) on delete cascade,
+    foreign key (project_id) references project (id) on delete cascade,
+    foreign key (category_id) references category (id) on delete cascade
+);
@@ -0,0 +1,15 @@
+-- append-only log of the changes made to projects, categories, rules and spans, so they can be reviewed and undone
+create table audit_log
+(
+    id      integer primary key autoincrement,
+    at      integer not null, -- Unix timestamp
+    action  text    not null, -- e.g. project.delete, span.split or undo
+    target  text    not null, -- JSON of the ids of the changed rows
+    before  text    not null, -- JSON of the changed rows before the change
+    after   text    not null, -- JSON of the changed rows after the change
+    undoes  integer,          -- the entry reverted by an undo
+    foreign key (undoes) references audit_log (id)
+);
+
+-- an entry can only be undone once
+create unique index idx_audit_log_undoes on audit_log (undoes);
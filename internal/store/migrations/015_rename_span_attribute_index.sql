@@ -0,0 +1,3 @@
+-- index names start with idx_ like the others
+drop index span_attribute_key_value;
+create index idx_span_attribute_key_value on span_attribute (key, value);
@@ -9,8 +9,8 @@ order by start_at desc
 limit 1;
 
 -- name: InsertSpan :one
-insert into span(app_name, window_title, start_at, end_at)
-values (@app_name, @window_title, @start_at, @end_at)
+insert into span(app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title)
+values (@app_name, @window_title, @start_at, @end_at, @app_id, @pid, @display, @raw_window_title)
 returning *;
 
 -- name: UpdateSpan :one
@@ -25,12 +25,41 @@ from span
 where start_at > @start_at
   and end_at < @end_at;
 
+-- name: SelectSpan :one
+select *
+from span
+where id = @id;
+
+-- name: SelectSpansBetween :many
+select *
+from span
+where start_at < @end_at
+  and end_at > @start_at
+order by start_at;
+
+-- name: UpdateSpanBounds :one
+update span
+set start_at = @start_at,
+    end_at   = @end_at
+where id = @id
+returning *;
+
+-- name: DeleteSpan :exec
+delete
+from span
+where id = @id;
+
+-- name: RestoreSpan :exec
+insert into span (id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title)
+values (@id, @app_name, @window_title, @start_at, @end_at, @app_id, @pid, @display, @raw_window_title);
+
 -- name: SelectCategorySpans :many
 with rule as ( select * from category_rule where category_rule.id = @category_id )
 select *
 from span
 where regexp_like(app_name, rule.pattern)
    or regexp_like(window_title, rule.pattern)
+   or regexp_like(app_id, rule.pattern)
 limit sqlc.arg('limit');
 
 
@@ -60,6 +89,15 @@ select *
 from category
 order by id;
 
+-- name: SelectCategory :one
+select *
+from category
+where id = @id;
+
+-- name: RestoreCategory :exec
+insert into category (id, name, color)
+values (@id, @name, @color);
+
 -----------------------------------------
 -- Projects
 -----------------------------------------
@@ -86,6 +124,20 @@ select *
 from project
 order by id;
 
+-- name: SelectProjectByName :one
+select *
+from project
+where lower(name) = lower(@name);
+
+-- name: SelectProject :one
+select *
+from project
+where id = @id;
+
+-- name: RestoreProject :exec
+insert into project (id, name, color)
+values (@id, @name, @color);
+
 -----------------------------------------
 -- Category Rules
 -----------------------------------------
@@ -114,6 +166,21 @@ from category_rule cr
          join category c on cr.category_id = c.id
 order by c.id, cr.id;
 
+-- name: SelectCategoryRule :one
+select *
+from category_rule
+where id = @id;
+
+-- name: SelectCategoryRuleIDs :many
+select id
+from category_rule
+where category_id = @category_id
+order by id;
+
+-- name: RestoreCategoryRule :exec
+insert into category_rule (id, pattern, category_id, is_active)
+values (@id, @pattern, @category_id, @is_active);
+
 -----------------------------------------
 -- Project Rules
 -----------------------------------------
@@ -140,4 +207,354 @@ where id = @id;
 select pr.id, pr.pattern, pr.project_id, pr.is_active, p.name, p.color
 from project_rule pr
          join project p on pr.project_id = p.id
-order by p.id, pr.id;
\ No newline at end of file
+order by p.id, pr.id;
+
+-- name: SelectProjectRule :one
+select *
+from project_rule
+where id = @id;
+
+-- name: SelectProjectRuleIDs :many
+select id
+from project_rule
+where project_id = @project_id
+order by id;
+
+-- name: RestoreProjectRule :exec
+insert into project_rule (id, pattern, project_id, is_active)
+values (@id, @pattern, @project_id, @is_active);
+
+-----------------------------------------
+-- Away Spans
+-----------------------------------------
+
+-- name: SelectLatestAwaySpan :one
+select *
+from away_span
+order by start_at desc
+limit 1;
+
+-- name: InsertAwaySpan :one
+insert into away_span(kind, reason, start_at, end_at)
+values (@kind, @reason, @start_at, @end_at)
+returning *;
+
+-- name: UpdateAwaySpan :one
+update away_span
+set end_at = @end_at
+where id = @id
+returning *;
+
+-- name: SelectAwaySpans :many
+select *
+from away_span
+where start_at > @start_at
+  and end_at < @end_at;
+
+-- name: SelectAwaySpansBetween :many
+select *
+from away_span
+where start_at < @end_at
+  and end_at > @start_at
+order by start_at;
+
+-----------------------------------------
+-- Browser Tabs
+-----------------------------------------
+
+-- name: UpsertSpanBrowserTab :exec
+insert into span_browser_tab(span_id, url, domain, title, incognito)
+values (@span_id, @url, @domain, @title, @incognito)
+on conflict (span_id) do update
+    set url       = excluded.url,
+        domain    = excluded.domain,
+        title     = excluded.title,
+        incognito = excluded.incognito;
+
+-- name: SelectSpanBrowserTabs :many
+select span_browser_tab.*
+from span_browser_tab
+         join span on span.id = span_browser_tab.span_id
+where span.start_at > @start_at
+  and span.end_at < @end_at;
+
+-- name: CopySpanBrowserTab :exec
+insert or ignore into span_browser_tab(span_id, url, domain, title, incognito)
+select @to_span_id, url, domain, title, incognito
+from span_browser_tab
+where span_id = @from_span_id;
+
+-- name: DeleteSpanBrowserTab :exec
+delete
+from span_browser_tab
+where span_id = @span_id;
+
+-- name: SelectSpanBrowserTab :one
+select *
+from span_browser_tab
+where span_id = @span_id;
+
+-----------------------------------------
+-- Heartbeats
+-----------------------------------------
+
+-- name: InsertHeartbeat :one
+insert into heartbeat(time, entity, type, category, project, branch, language, is_write, user_agent)
+values (@time, @entity, @type, @category, @project, @branch, @language, @is_write, @user_agent)
+on conflict (time, entity) do update
+    set is_write = max(heartbeat.is_write, excluded.is_write)
+returning *;
+
+-- name: SelectHeartbeats :many
+select *
+from heartbeat
+where time >= @start_at
+  and time < @end_at
+order by time;
+
+-----------------------------------------
+-- Terminal Context
+-----------------------------------------
+
+-- name: UpsertTerminalContext :exec
+insert into terminal_context(span_id, cwd, git_root, command, source)
+values (@span_id, @cwd, @git_root, @command, @source)
+on conflict (span_id) do update
+    set cwd      = excluded.cwd,
+        git_root = excluded.git_root,
+        command  = excluded.command,
+        source   = excluded.source;
+
+-- name: SelectTerminalContexts :many
+select terminal_context.*
+from terminal_context
+         join span on span.id = terminal_context.span_id
+where span.start_at > @start_at
+  and span.end_at < @end_at;
+
+-- name: CopyTerminalContext :exec
+insert or ignore into terminal_context(span_id, cwd, git_root, command, source)
+select @to_span_id, cwd, git_root, command, source
+from terminal_context
+where span_id = @from_span_id;
+
+-- name: DeleteTerminalContext :exec
+delete
+from terminal_context
+where span_id = @span_id;
+
+-- name: SelectTerminalContext :one
+select *
+from terminal_context
+where span_id = @span_id;
+
+-----------------------------------------
+-- Span Attributes
+-----------------------------------------
+
+-- name: UpsertSpanAttribute :exec
+insert into span_attribute(span_id, key, value, type)
+values (@span_id, @key, @value, @type)
+on conflict (span_id, key) do update
+    set value = excluded.value,
+        type  = excluded.type;
+
+-- name: SelectSpanAttributes :many
+select span_attribute.*
+from span_attribute
+         join span on span.id = span_attribute.span_id
+where span.start_at > @start_at
+  and span.end_at < @end_at;
+
+-- name: SelectAttributeValues :many
+select span_attribute.key, span_attribute.value, span_attribute.type, count(*) as span_count
+from span_attribute
+         join span on span.id = span_attribute.span_id
+where span.start_at > @start_at
+  and span.end_at < @end_at
+group by span_attribute.key, span_attribute.value, span_attribute.type
+order by span_attribute.key, span_count desc;
+
+-- name: CopySpanAttributes :exec
+insert or ignore into span_attribute(span_id, key, value, type)
+select @to_span_id, key, value, type
+from span_attribute
+where span_id = @from_span_id;
+
+-- name: DeleteSpanAttributes :exec
+delete
+from span_attribute
+where span_id = @span_id;
+
+-- name: SelectSpanAttributesBySpan :many
+select *
+from span_attribute
+where span_id = @span_id
+order by key;
+
+-----------------------------------------
+-- Span Pins
+-----------------------------------------
+
+-- name: UpsertSpanPin :exec
+insert into span_pin(span_id, project_id, category_id)
+values (@span_id, @project_id, @category_id)
+on conflict (span_id) do update
+    set project_id  = coalesce(excluded.project_id, span_pin.project_id),
+        category_id = coalesce(excluded.category_id, span_pin.category_id);
+
+-- name: SelectSpanPins :many
+select span_pin.*
+from span_pin
+         join span on span.id = span_pin.span_id
+where span.start_at > @start_at
+  and span.end_at < @end_at;
+
+-- name: CopySpanPin :exec
+insert or ignore into span_pin(span_id, project_id, category_id)
+select @to_span_id, project_id, category_id
+from span_pin
+where span_id = @from_span_id;
+
+-- name: DeleteSpanPin :exec
+delete
+from span_pin
+where span_id = @span_id;
+
+-- name: SelectSpanPin :one
+select *
+from span_pin
+where span_id = @span_id;
+
+-- name: SelectProjectPinnedSpanIDs :many
+select span_id
+from span_pin
+where project_id = @project_id
+order by span_id;
+
+-- name: SelectCategoryPinnedSpanIDs :many
+select span_id
+from span_pin
+where category_id = @category_id
+order by span_id;
+
+-- name: ClearSpanPinProject :exec
+update span_pin
+set project_id = null
+where project_id = @project_id;
+
+-- name: ClearSpanPinCategory :exec
+update span_pin
+set category_id = null
+where category_id = @category_id;
+
+-- name: DeleteEmptySpanPins :exec
+delete
+from span_pin
+where project_id is null
+  and category_id is null;
+
+-----------------------------------------
+-- Manual Entries
+-----------------------------------------
+
+-- name: InsertManualEntry :one
+insert into manual_entry(kind, project_id, note, start_at, end_at)
+values (@kind, @project_id, @note, @start_at, @end_at)
+returning *;
+
+-- name: StopManualEntry :one
+update manual_entry
+set end_at = @end_at
+where end_at = 0
+returning *;
+
+-- name: SelectRunningManualEntry :one
+select m.id, m.project_id, m.note, m.start_at, m.end_at, p.name, p.color
+from manual_entry m
+         left join project p on m.project_id = p.id
+where m.end_at = 0;
+
+-- name: SelectManualEntries :many
+select m.id, m.project_id, m.note, m.start_at, m.end_at, m.kind, p.name, p.color
+from manual_entry m
+         left join project p on m.project_id = p.id
+where m.start_at < @end_at
+  and (m.end_at = 0 or m.end_at > @start_at)
+order by m.start_at;
+
+-- name: SelectManualEntry :one
+select *
+from manual_entry
+where id = @id;
+
+-- name: SelectProjectManualEntryIDs :many
+select id
+from manual_entry
+where project_id = @project_id
+order by id;
+
+-- name: ClearManualEntryProject :exec
+update manual_entry
+set project_id = null
+where project_id = @project_id;
+
+-- name: DeleteManualEntry :exec
+delete
+from manual_entry
+where id = @id;
+
+-- name: RestoreManualEntry :exec
+insert into manual_entry (id, project_id, note, start_at, end_at, kind)
+values (@id, @project_id, @note, @start_at, @end_at, @kind);
+
+-----------------------------------------
+-- Unaccounted Gaps
+-----------------------------------------
+
+-- name: InsertUnaccountedGap :one
+insert into unaccounted_gap(start_at, end_at, kind)
+values (@start_at, @end_at, @kind)
+returning *;
+
+-- name: SelectPendingGaps :many
+select *
+from unaccounted_gap
+where resolution = ''
+order by start_at;
+
+-- name: SelectUnaccountedGap :one
+select *
+from unaccounted_gap
+where id = @id;
+
+-- name: ResolveUnaccountedGap :one
+update unaccounted_gap
+set resolution      = @resolution,
+    manual_entry_id = @manual_entry_id
+where id = @id
+  and resolution = ''
+returning *;
+
+-----------------------------------------
+-- Audit Log
+-----------------------------------------
+
+-- name: InsertAuditLog :one
+insert into audit_log(at, action, target, before, after, undoes)
+values (@at, @action, @target, @before, @after, @undoes)
+returning *;
+
+-- name: SelectAuditLog :one
+select a.id, a.at, a.action, a.target, a.before, a.after, a.undoes, u.id as undone_by
+from audit_log a
+         left join audit_log u on u.undoes = a.id
+where a.id = @id;
+
+-- name: SelectAuditLogs :many
+select a.id, a.at, a.action, a.target, a.before, a.after, a.undoes, u.id as undone_by
+from audit_log a
+         left join audit_log u on u.undoes = a.id
+where a.id < @before_id
+order by a.id desc
+limit @limit;
@@ -9,6 +9,107 @@ import (
 	"context"
 )
 
+const clearManualEntryProject = `-- name: ClearManualEntryProject :exec
+update manual_entry
+set project_id = null
+where project_id = ?1
+`
+
+func (q *Queries) ClearManualEntryProject(ctx context.Context, projectID *int64) error {
+	_, err := q.db.ExecContext(ctx, clearManualEntryProject, projectID)
+	return err
+}
+
+const clearSpanPinCategory = `-- name: ClearSpanPinCategory :exec
+update span_pin
+set category_id = null
+where category_id = ?1
+`
+
+func (q *Queries) ClearSpanPinCategory(ctx context.Context, categoryID *int64) error {
+	_, err := q.db.ExecContext(ctx, clearSpanPinCategory, categoryID)
+	return err
+}
+
+const clearSpanPinProject = `-- name: ClearSpanPinProject :exec
+update span_pin
+set project_id = null
+where project_id = ?1
+`
+
+func (q *Queries) ClearSpanPinProject(ctx context.Context, projectID *int64) error {
+	_, err := q.db.ExecContext(ctx, clearSpanPinProject, projectID)
+	return err
+}
+
+const copySpanAttributes = `-- name: CopySpanAttributes :exec
+insert or ignore into span_attribute(span_id, key, value, type)
+select ?1, key, value, type
+from span_attribute
+where span_id = ?2
+`
+
+type CopySpanAttributesParams struct {
+	ToSpanID   int64 `json:"to_span_id"`
+	FromSpanID int64 `json:"from_span_id"`
+}
+
+func (q *Queries) CopySpanAttributes(ctx context.Context, arg CopySpanAttributesParams) error {
+	_, err := q.db.ExecContext(ctx, copySpanAttributes, arg.ToSpanID, arg.FromSpanID)
+	return err
+}
+
+const copySpanBrowserTab = `-- name: CopySpanBrowserTab :exec
+insert or ignore into span_browser_tab(span_id, url, domain, title, incognito)
+select ?1, url, domain, title, incognito
+from span_browser_tab
+where span_id = ?2
+`
+
+type CopySpanBrowserTabParams struct {
+	ToSpanID   int64 `json:"to_span_id"`
+	FromSpanID int64 `json:"from_span_id"`
+}
+
+func (q *Queries) CopySpanBrowserTab(ctx context.Context, arg CopySpanBrowserTabParams) error {
+	_, err := q.db.ExecContext(ctx, copySpanBrowserTab, arg.ToSpanID, arg.FromSpanID)
+	return err
+}
+
+const copySpanPin = `-- name: CopySpanPin :exec
+insert or ignore into span_pin(span_id, project_id, category_id)
+select ?1, project_id, category_id
+from span_pin
+where span_id = ?2
+`
+
+type CopySpanPinParams struct {
+	ToSpanID   int64 `json:"to_span_id"`
+	FromSpanID int64 `json:"from_span_id"`
+}
+
+func (q *Queries) CopySpanPin(ctx context.Context, arg CopySpanPinParams) error {
+	_, err := q.db.ExecContext(ctx, copySpanPin, arg.ToSpanID, arg.FromSpanID)
+	return err
+}
+
+const copyTerminalContext = `-- name: CopyTerminalContext :exec
+insert or ignore into terminal_context(span_id, cwd, git_root, command, source)
+select ?1, cwd, git_root, command, source
+from terminal_context
+where span_id = ?2
+`
+
+type CopyTerminalContextParams struct {
+	ToSpanID   int64 `json:"to_span_id"`
+	FromSpanID int64 `json:"from_span_id"`
+}
+
+func (q *Queries) CopyTerminalContext(ctx context.Context, arg CopyTerminalContextParams) error {
+	_, err := q.db.ExecContext(ctx, copyTerminalContext, arg.ToSpanID, arg.FromSpanID)
+	return err
+}
+
 const deleteCategory = `-- name: DeleteCategory :exec
 delete
 from category
@@ -31,6 +132,29 @@ func (q *Queries) DeleteCategoryRule(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteEmptySpanPins = `-- name: DeleteEmptySpanPins :exec
+delete
+from span_pin
+where project_id is null
+  and category_id is null
+`
+
+func (q *Queries) DeleteEmptySpanPins(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteEmptySpanPins)
+	return err
+}
+
+const deleteManualEntry = `-- name: DeleteManualEntry :exec
+delete
+from manual_entry
+where id = ?1
+`
+
+func (q *Queries) DeleteManualEntry(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteManualEntry, id)
+	return err
+}
+
 const deleteProject = `-- name: DeleteProject :exec
 delete
 from project
@@ -53,6 +177,133 @@ func (q *Queries) DeleteProjectRule(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteSpan = `-- name: DeleteSpan :exec
+delete
+from span
+where id = ?1
+`
+
+func (q *Queries) DeleteSpan(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSpan, id)
+	return err
+}
+
+const deleteSpanAttributes = `-- name: DeleteSpanAttributes :exec
+delete
+from span_attribute
+where span_id = ?1
+`
+
+func (q *Queries) DeleteSpanAttributes(ctx context.Context, spanID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSpanAttributes, spanID)
+	return err
+}
+
+const deleteSpanBrowserTab = `-- name: DeleteSpanBrowserTab :exec
+delete
+from span_browser_tab
+where span_id = ?1
+`
+
+func (q *Queries) DeleteSpanBrowserTab(ctx context.Context, spanID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSpanBrowserTab, spanID)
+	return err
+}
+
+const deleteSpanPin = `-- name: DeleteSpanPin :exec
+delete
+from span_pin
+where span_id = ?1
+`
+
+func (q *Queries) DeleteSpanPin(ctx context.Context, spanID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSpanPin, spanID)
+	return err
+}
+
+const deleteTerminalContext = `-- name: DeleteTerminalContext :exec
+delete
+from terminal_context
+where span_id = ?1
+`
+
+func (q *Queries) DeleteTerminalContext(ctx context.Context, spanID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTerminalContext, spanID)
+	return err
+}
+
+const insertAuditLog = `-- name: InsertAuditLog :one
+
+insert into audit_log(at, action, target, before, after, undoes)
+values (?1, ?2, ?3, ?4, ?5, ?6)
+returning id, at, action, target, before, after, undoes
+`
+
+type InsertAuditLogParams struct {
+	At     int64  `json:"at"`
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Undoes *int64 `json:"undoes"`
+}
+
+// ---------------------------------------
+// Audit Log
+// ---------------------------------------
+func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, insertAuditLog,
+		arg.At,
+		arg.Action,
+		arg.Target,
+		arg.Before,
+		arg.After,
+		arg.Undoes,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.At,
+		&i.Action,
+		&i.Target,
+		&i.Before,
+		&i.After,
+		&i.Undoes,
+	)
+	return i, err
+}
+
+const insertAwaySpan = `-- name: InsertAwaySpan :one
+insert into away_span(kind, reason, start_at, end_at)
+values (?1, ?2, ?3, ?4)
+returning id, kind, reason, start_at, end_at
+`
+
+type InsertAwaySpanParams struct {
+	Kind    string `json:"kind"`
+	Reason  string `json:"reason"`
+	StartAt int64  `json:"start_at"`
+	EndAt   int64  `json:"end_at"`
+}
+
+func (q *Queries) InsertAwaySpan(ctx context.Context, arg InsertAwaySpanParams) (AwaySpan, error) {
+	row := q.db.QueryRowContext(ctx, insertAwaySpan,
+		arg.Kind,
+		arg.Reason,
+		arg.StartAt,
+		arg.EndAt,
+	)
+	var i AwaySpan
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Reason,
+		&i.StartAt,
+		&i.EndAt,
+	)
+	return i, err
+}
+
 const insertCategory = `-- name: InsertCategory :one
 
 insert into category (name, color)
@@ -103,6 +354,96 @@ func (q *Queries) InsertCategoryRule(ctx context.Context, arg InsertCategoryRule
 	return i, err
 }
 
+const insertHeartbeat = `-- name: InsertHeartbeat :one
+
+insert into heartbeat(time, entity, type, category, project, branch, language, is_write, user_agent)
+values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+on conflict (time, entity) do update
+    set is_write = max(heartbeat.is_write, excluded.is_write)
+returning id, time, entity, type, category, project, branch, language, is_write, user_agent
+`
+
+type InsertHeartbeatParams struct {
+	Time      int64  `json:"time"`
+	Entity    string `json:"entity"`
+	Type      string `json:"type"`
+	Category  string `json:"category"`
+	Project   string `json:"project"`
+	Branch    string `json:"branch"`
+	Language  string `json:"language"`
+	IsWrite   bool   `json:"is_write"`
+	UserAgent string `json:"user_agent"`
+}
+
+// ---------------------------------------
+// Heartbeats
+// ---------------------------------------
+func (q *Queries) InsertHeartbeat(ctx context.Context, arg InsertHeartbeatParams) (Heartbeat, error) {
+	row := q.db.QueryRowContext(ctx, insertHeartbeat,
+		arg.Time,
+		arg.Entity,
+		arg.Type,
+		arg.Category,
+		arg.Project,
+		arg.Branch,
+		arg.Language,
+		arg.IsWrite,
+		arg.UserAgent,
+	)
+	var i Heartbeat
+	err := row.Scan(
+		&i.ID,
+		&i.Time,
+		&i.Entity,
+		&i.Type,
+		&i.Category,
+		&i.Project,
+		&i.Branch,
+		&i.Language,
+		&i.IsWrite,
+		&i.UserAgent,
+	)
+	return i, err
+}
+
+const insertManualEntry = `-- name: InsertManualEntry :one
+
+insert into manual_entry(kind, project_id, note, start_at, end_at)
+values (?1, ?2, ?3, ?4, ?5)
+returning id, project_id, note, start_at, end_at, kind
+`
+
+type InsertManualEntryParams struct {
+	Kind      string `json:"kind"`
+	ProjectID *int64 `json:"project_id"`
+	Note      string `json:"note"`
+	StartAt   int64  `json:"start_at"`
+	EndAt     int64  `json:"end_at"`
+}
+
+// ---------------------------------------
+// Manual Entries
+// ---------------------------------------
+func (q *Queries) InsertManualEntry(ctx context.Context, arg InsertManualEntryParams) (ManualEntry, error) {
+	row := q.db.QueryRowContext(ctx, insertManualEntry,
+		arg.Kind,
+		arg.ProjectID,
+		arg.Note,
+		arg.StartAt,
+		arg.EndAt,
+	)
+	var i ManualEntry
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Note,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Kind,
+	)
+	return i, err
+}
+
 const insertProject = `-- name: InsertProject :one
 
 insert into project (name, color)
@@ -154,16 +495,20 @@ func (q *Queries) InsertProjectRule(ctx context.Context, arg InsertProjectRulePa
 }
 
 const insertSpan = `-- name: InsertSpan :one
-insert into span(app_name, window_title, start_at, end_at)
-values (?1, ?2, ?3, ?4)
-returning id, app_name, window_title, start_at, end_at
+insert into span(app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title)
+values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+returning id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
 `
 
 type InsertSpanParams struct {
-	AppName     string `json:"app_name"`
-	WindowTitle string `json:"window_title"`
-	StartAt     int64  `json:"start_at"`
-	EndAt       int64  `json:"end_at"`
+	AppName        string `json:"app_name"`
+	WindowTitle    string `json:"window_title"`
+	StartAt        int64  `json:"start_at"`
+	EndAt          int64  `json:"end_at"`
+	AppID          string `json:"app_id"`
+	Pid            int64  `json:"pid"`
+	Display        string `json:"display"`
+	RawWindowTitle string `json:"raw_window_title"`
 }
 
 func (q *Queries) InsertSpan(ctx context.Context, arg InsertSpanParams) (Span, error) {
@@ -172,6 +517,10 @@ func (q *Queries) InsertSpan(ctx context.Context, arg InsertSpanParams) (Span, e
 		arg.WindowTitle,
 		arg.StartAt,
 		arg.EndAt,
+		arg.AppID,
+		arg.Pid,
+		arg.Display,
+		arg.RawWindowTitle,
 	)
 	var i Span
 	err := row.Scan(
@@ -180,71 +529,1149 @@ func (q *Queries) InsertSpan(ctx context.Context, arg InsertSpanParams) (Span, e
 		&i.WindowTitle,
 		&i.StartAt,
 		&i.EndAt,
+		&i.AppID,
+		&i.Pid,
+		&i.Display,
+		&i.RawWindowTitle,
 	)
 	return i, err
 }
 
-const selectCategories = `-- name: SelectCategories :many
-select id, name, color
-from category
-order by id
+const insertUnaccountedGap = `-- name: InsertUnaccountedGap :one
+
+insert into unaccounted_gap(start_at, end_at, kind)
+values (?1, ?2, ?3)
+returning id, start_at, end_at, kind, resolution, manual_entry_id
 `
 
-func (q *Queries) SelectCategories(ctx context.Context) ([]Category, error) {
-	rows, err := q.db.QueryContext(ctx, selectCategories)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Category
-	for rows.Next() {
-		var i Category
-		if err := rows.Scan(&i.ID, &i.Name, &i.Color); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type InsertUnaccountedGapParams struct {
+	StartAt int64  `json:"start_at"`
+	EndAt   int64  `json:"end_at"`
+	Kind    string `json:"kind"`
 }
 
-const selectCategoryRules = `-- name: SelectCategoryRules :many
-select cr.id, cr.pattern, cr.category_id, cr.is_active, c.name, c.color
-from category_rule cr
-         join category c on cr.category_id = c.id
-order by c.id, cr.id
+// ---------------------------------------
+// Unaccounted Gaps
+// ---------------------------------------
+func (q *Queries) InsertUnaccountedGap(ctx context.Context, arg InsertUnaccountedGapParams) (UnaccountedGap, error) {
+	row := q.db.QueryRowContext(ctx, insertUnaccountedGap, arg.StartAt, arg.EndAt, arg.Kind)
+	var i UnaccountedGap
+	err := row.Scan(
+		&i.ID,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Kind,
+		&i.Resolution,
+		&i.ManualEntryID,
+	)
+	return i, err
+}
+
+const resolveUnaccountedGap = `-- name: ResolveUnaccountedGap :one
+update unaccounted_gap
+set resolution      = ?1,
+    manual_entry_id = ?2
+where id = ?3
+  and resolution = ''
+returning id, start_at, end_at, kind, resolution, manual_entry_id
 `
 
-type SelectCategoryRulesRow struct {
+type ResolveUnaccountedGapParams struct {
+	Resolution    string `json:"resolution"`
+	ManualEntryID *int64 `json:"manual_entry_id"`
+	ID            int64  `json:"id"`
+}
+
+func (q *Queries) ResolveUnaccountedGap(ctx context.Context, arg ResolveUnaccountedGapParams) (UnaccountedGap, error) {
+	row := q.db.QueryRowContext(ctx, resolveUnaccountedGap, arg.Resolution, arg.ManualEntryID, arg.ID)
+	var i UnaccountedGap
+	err := row.Scan(
+		&i.ID,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Kind,
+		&i.Resolution,
+		&i.ManualEntryID,
+	)
+	return i, err
+}
+
+const restoreCategory = `-- name: RestoreCategory :exec
+insert into category (id, name, color)
+values (?1, ?2, ?3)
+`
+
+type RestoreCategoryParams struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+func (q *Queries) RestoreCategory(ctx context.Context, arg RestoreCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, restoreCategory, arg.ID, arg.Name, arg.Color)
+	return err
+}
+
+const restoreCategoryRule = `-- name: RestoreCategoryRule :exec
+insert into category_rule (id, pattern, category_id, is_active)
+values (?1, ?2, ?3, ?4)
+`
+
+type RestoreCategoryRuleParams struct {
 	ID         int64  `json:"id"`
 	Pattern    string `json:"pattern"`
 	CategoryID int64  `json:"category_id"`
 	IsActive   bool   `json:"is_active"`
-	Name       string `json:"name"`
-	Color      string `json:"color"`
 }
 
-func (q *Queries) SelectCategoryRules(ctx context.Context) ([]SelectCategoryRulesRow, error) {
-	rows, err := q.db.QueryContext(ctx, selectCategoryRules)
+func (q *Queries) RestoreCategoryRule(ctx context.Context, arg RestoreCategoryRuleParams) error {
+	_, err := q.db.ExecContext(ctx, restoreCategoryRule,
+		arg.ID,
+		arg.Pattern,
+		arg.CategoryID,
+		arg.IsActive,
+	)
+	return err
+}
+
+const restoreManualEntry = `-- name: RestoreManualEntry :exec
+insert into manual_entry (id, project_id, note, start_at, end_at, kind)
+values (?1, ?2, ?3, ?4, ?5, ?6)
+`
+
+type RestoreManualEntryParams struct {
+	ID        int64  `json:"id"`
+	ProjectID *int64 `json:"project_id"`
+	Note      string `json:"note"`
+	StartAt   int64  `json:"start_at"`
+	EndAt     int64  `json:"end_at"`
+	Kind      string `json:"kind"`
+}
+
+func (q *Queries) RestoreManualEntry(ctx context.Context, arg RestoreManualEntryParams) error {
+	_, err := q.db.ExecContext(ctx, restoreManualEntry,
+		arg.ID,
+		arg.ProjectID,
+		arg.Note,
+		arg.StartAt,
+		arg.EndAt,
+		arg.Kind,
+	)
+	return err
+}
+
+const restoreProject = `-- name: RestoreProject :exec
+insert into project (id, name, color)
+values (?1, ?2, ?3)
+`
+
+type RestoreProjectParams struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+func (q *Queries) RestoreProject(ctx context.Context, arg RestoreProjectParams) error {
+	_, err := q.db.ExecContext(ctx, restoreProject, arg.ID, arg.Name, arg.Color)
+	return err
+}
+
+const restoreProjectRule = `-- name: RestoreProjectRule :exec
+insert into project_rule (id, pattern, project_id, is_active)
+values (?1, ?2, ?3, ?4)
+`
+
+type RestoreProjectRuleParams struct {
+	ID        int64  `json:"id"`
+	Pattern   string `json:"pattern"`
+	ProjectID int64  `json:"project_id"`
+	IsActive  bool   `json:"is_active"`
+}
+
+func (q *Queries) RestoreProjectRule(ctx context.Context, arg RestoreProjectRuleParams) error {
+	_, err := q.db.ExecContext(ctx, restoreProjectRule,
+		arg.ID,
+		arg.Pattern,
+		arg.ProjectID,
+		arg.IsActive,
+	)
+	return err
+}
+
+const restoreSpan = `-- name: RestoreSpan :exec
+insert into span (id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title)
+values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+`
+
+type RestoreSpanParams struct {
+	ID             int64  `json:"id"`
+	AppName        string `json:"app_name"`
+	WindowTitle    string `json:"window_title"`
+	StartAt        int64  `json:"start_at"`
+	EndAt          int64  `json:"end_at"`
+	AppID          string `json:"app_id"`
+	Pid            int64  `json:"pid"`
+	Display        string `json:"display"`
+	RawWindowTitle string `json:"raw_window_title"`
+}
+
+func (q *Queries) RestoreSpan(ctx context.Context, arg RestoreSpanParams) error {
+	_, err := q.db.ExecContext(ctx, restoreSpan,
+		arg.ID,
+		arg.AppName,
+		arg.WindowTitle,
+		arg.StartAt,
+		arg.EndAt,
+		arg.AppID,
+		arg.Pid,
+		arg.Display,
+		arg.RawWindowTitle,
+	)
+	return err
+}
+
+const selectAttributeValues = `-- name: SelectAttributeValues :many
+select span_attribute.key, span_attribute.value, span_attribute.type, count(*) as span_count
+from span_attribute
+         join span on span.id = span_attribute.span_id
+where span.start_at > ?1
+  and span.end_at < ?2
+group by span_attribute.key, span_attribute.value, span_attribute.type
+order by span_attribute.key, span_count desc
+`
+
+type SelectAttributeValuesParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+type SelectAttributeValuesRow struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Type      string `json:"type"`
+	SpanCount int64  `json:"span_count"`
+}
+
+func (q *Queries) SelectAttributeValues(ctx context.Context, arg SelectAttributeValuesParams) ([]SelectAttributeValuesRow, error) {
+	rows, err := q.db.QueryContext(ctx, selectAttributeValues, arg.StartAt, arg.EndAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SelectAttributeValuesRow
+	for rows.Next() {
+		var i SelectAttributeValuesRow
+		if err := rows.Scan(
+			&i.Key,
+			&i.Value,
+			&i.Type,
+			&i.SpanCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectAuditLog = `-- name: SelectAuditLog :one
+select a.id, a.at, a.action, a.target, a.before, a.after, a.undoes, u.id as undone_by
+from audit_log a
+         left join audit_log u on u.undoes = a.id
+where a.id = ?1
+`
+
+type SelectAuditLogRow struct {
+	ID       int64  `json:"id"`
+	At       int64  `json:"at"`
+	Action   string `json:"action"`
+	Target   string `json:"target"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+	Undoes   *int64 `json:"undoes"`
+	UndoneBy *int64 `json:"undone_by"`
+}
+
+func (q *Queries) SelectAuditLog(ctx context.Context, id int64) (SelectAuditLogRow, error) {
+	row := q.db.QueryRowContext(ctx, selectAuditLog, id)
+	var i SelectAuditLogRow
+	err := row.Scan(
+		&i.ID,
+		&i.At,
+		&i.Action,
+		&i.Target,
+		&i.Before,
+		&i.After,
+		&i.Undoes,
+		&i.UndoneBy,
+	)
+	return i, err
+}
+
+const selectAuditLogs = `-- name: SelectAuditLogs :many
+select a.id, a.at, a.action, a.target, a.before, a.after, a.undoes, u.id as undone_by
+from audit_log a
+         left join audit_log u on u.undoes = a.id
+where a.id < ?1
+order by a.id desc
+limit ?2
+`
+
+type SelectAuditLogsParams struct {
+	BeforeID int64 `json:"before_id"`
+	Limit    int64 `json:"limit"`
+}
+
+type SelectAuditLogsRow struct {
+	ID       int64  `json:"id"`
+	At       int64  `json:"at"`
+	Action   string `json:"action"`
+	Target   string `json:"target"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+	Undoes   *int64 `json:"undoes"`
+	UndoneBy *int64 `json:"undone_by"`
+}
+
+func (q *Queries) SelectAuditLogs(ctx context.Context, arg SelectAuditLogsParams) ([]SelectAuditLogsRow, error) {
+	rows, err := q.db.QueryContext(ctx, selectAuditLogs, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SelectAuditLogsRow
+	for rows.Next() {
+		var i SelectAuditLogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.At,
+			&i.Action,
+			&i.Target,
+			&i.Before,
+			&i.After,
+			&i.Undoes,
+			&i.UndoneBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectAwaySpans = `-- name: SelectAwaySpans :many
+select id, kind, reason, start_at, end_at
+from away_span
+where start_at > ?1
+  and end_at < ?2
+`
+
+type SelectAwaySpansParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+func (q *Queries) SelectAwaySpans(ctx context.Context, arg SelectAwaySpansParams) ([]AwaySpan, error) {
+	rows, err := q.db.QueryContext(ctx, selectAwaySpans, arg.StartAt, arg.EndAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AwaySpan
+	for rows.Next() {
+		var i AwaySpan
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Reason,
+			&i.StartAt,
+			&i.EndAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectAwaySpansBetween = `-- name: SelectAwaySpansBetween :many
+select id, kind, reason, start_at, end_at
+from away_span
+where start_at < ?1
+  and end_at > ?2
+order by start_at
+`
+
+type SelectAwaySpansBetweenParams struct {
+	EndAt   int64 `json:"end_at"`
+	StartAt int64 `json:"start_at"`
+}
+
+func (q *Queries) SelectAwaySpansBetween(ctx context.Context, arg SelectAwaySpansBetweenParams) ([]AwaySpan, error) {
+	rows, err := q.db.QueryContext(ctx, selectAwaySpansBetween, arg.EndAt, arg.StartAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AwaySpan
+	for rows.Next() {
+		var i AwaySpan
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Reason,
+			&i.StartAt,
+			&i.EndAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectCategories = `-- name: SelectCategories :many
+select id, name, color
+from category
+order by id
+`
+
+func (q *Queries) SelectCategories(ctx context.Context) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, selectCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(&i.ID, &i.Name, &i.Color); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectCategory = `-- name: SelectCategory :one
+select id, name, color
+from category
+where id = ?1
+`
+
+func (q *Queries) SelectCategory(ctx context.Context, id int64) (Category, error) {
+	row := q.db.QueryRowContext(ctx, selectCategory, id)
+	var i Category
+	err := row.Scan(&i.ID, &i.Name, &i.Color)
+	return i, err
+}
+
+const selectCategoryPinnedSpanIDs = `-- name: SelectCategoryPinnedSpanIDs :many
+select span_id
+from span_pin
+where category_id = ?1
+order by span_id
+`
+
+func (q *Queries) SelectCategoryPinnedSpanIDs(ctx context.Context, categoryID *int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, selectCategoryPinnedSpanIDs, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var span_id int64
+		if err := rows.Scan(&span_id); err != nil {
+			return nil, err
+		}
+		items = append(items, span_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectCategoryRule = `-- name: SelectCategoryRule :one
+select id, pattern, category_id, is_active
+from category_rule
+where id = ?1
+`
+
+func (q *Queries) SelectCategoryRule(ctx context.Context, id int64) (CategoryRule, error) {
+	row := q.db.QueryRowContext(ctx, selectCategoryRule, id)
+	var i CategoryRule
+	err := row.Scan(
+		&i.ID,
+		&i.Pattern,
+		&i.CategoryID,
+		&i.IsActive,
+	)
+	return i, err
+}
+
+const selectCategoryRuleIDs = `-- name: SelectCategoryRuleIDs :many
+select id
+from category_rule
+where category_id = ?1
+order by id
+`
+
+func (q *Queries) SelectCategoryRuleIDs(ctx context.Context, categoryID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, selectCategoryRuleIDs, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectCategoryRules = `-- name: SelectCategoryRules :many
+select cr.id, cr.pattern, cr.category_id, cr.is_active, c.name, c.color
+from category_rule cr
+         join category c on cr.category_id = c.id
+order by c.id, cr.id
+`
+
+type SelectCategoryRulesRow struct {
+	ID         int64  `json:"id"`
+	Pattern    string `json:"pattern"`
+	CategoryID int64  `json:"category_id"`
+	IsActive   bool   `json:"is_active"`
+	Name       string `json:"name"`
+	Color      string `json:"color"`
+}
+
+func (q *Queries) SelectCategoryRules(ctx context.Context) ([]SelectCategoryRulesRow, error) {
+	rows, err := q.db.QueryContext(ctx, selectCategoryRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SelectCategoryRulesRow
+	for rows.Next() {
+		var i SelectCategoryRulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Pattern,
+			&i.CategoryID,
+			&i.IsActive,
+			&i.Name,
+			&i.Color,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectCategorySpans = `-- name: SelectCategorySpans :many
+with rule as ( select id, pattern, category_id, is_active from category_rule where category_rule.id = ?2 )
+select id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
+from span
+where regexp_like(app_name, rule.pattern)
+   or regexp_like(window_title, rule.pattern)
+   or regexp_like(app_id, rule.pattern)
+limit ?1
+`
+
+type SelectCategorySpansParams struct {
+	Limit      int64 `json:"limit"`
+	CategoryID int64 `json:"category_id"`
+}
+
+func (q *Queries) SelectCategorySpans(ctx context.Context, arg SelectCategorySpansParams) ([]Span, error) {
+	rows, err := q.db.QueryContext(ctx, selectCategorySpans, arg.Limit, arg.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Span
+	for rows.Next() {
+		var i Span
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppName,
+			&i.WindowTitle,
+			&i.StartAt,
+			&i.EndAt,
+			&i.AppID,
+			&i.Pid,
+			&i.Display,
+			&i.RawWindowTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectHeartbeats = `-- name: SelectHeartbeats :many
+select id, time, entity, type, category, project, branch, language, is_write, user_agent
+from heartbeat
+where time >= ?1
+  and time < ?2
+order by time
+`
+
+type SelectHeartbeatsParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+func (q *Queries) SelectHeartbeats(ctx context.Context, arg SelectHeartbeatsParams) ([]Heartbeat, error) {
+	rows, err := q.db.QueryContext(ctx, selectHeartbeats, arg.StartAt, arg.EndAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Heartbeat
+	for rows.Next() {
+		var i Heartbeat
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+			&i.Entity,
+			&i.Type,
+			&i.Category,
+			&i.Project,
+			&i.Branch,
+			&i.Language,
+			&i.IsWrite,
+			&i.UserAgent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectLatestAwaySpan = `-- name: SelectLatestAwaySpan :one
+
+select id, kind, reason, start_at, end_at
+from away_span
+order by start_at desc
+limit 1
+`
+
+// ---------------------------------------
+// Away Spans
+// ---------------------------------------
+func (q *Queries) SelectLatestAwaySpan(ctx context.Context) (AwaySpan, error) {
+	row := q.db.QueryRowContext(ctx, selectLatestAwaySpan)
+	var i AwaySpan
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Reason,
+		&i.StartAt,
+		&i.EndAt,
+	)
+	return i, err
+}
+
+const selectLatestSpan = `-- name: SelectLatestSpan :one
+
+select id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
+from span
+order by start_at desc
+limit 1
+`
+
+// ---------------------------------------
+// Spans
+// ---------------------------------------
+func (q *Queries) SelectLatestSpan(ctx context.Context) (Span, error) {
+	row := q.db.QueryRowContext(ctx, selectLatestSpan)
+	var i Span
+	err := row.Scan(
+		&i.ID,
+		&i.AppName,
+		&i.WindowTitle,
+		&i.StartAt,
+		&i.EndAt,
+		&i.AppID,
+		&i.Pid,
+		&i.Display,
+		&i.RawWindowTitle,
+	)
+	return i, err
+}
+
+const selectManualEntries = `-- name: SelectManualEntries :many
+select m.id, m.project_id, m.note, m.start_at, m.end_at, m.kind, p.name, p.color
+from manual_entry m
+         left join project p on m.project_id = p.id
+where m.start_at < ?1
+  and (m.end_at = 0 or m.end_at > ?2)
+order by m.start_at
+`
+
+type SelectManualEntriesParams struct {
+	EndAt   int64 `json:"end_at"`
+	StartAt int64 `json:"start_at"`
+}
+
+type SelectManualEntriesRow struct {
+	ID        int64   `json:"id"`
+	ProjectID *int64  `json:"project_id"`
+	Note      string  `json:"note"`
+	StartAt   int64   `json:"start_at"`
+	EndAt     int64   `json:"end_at"`
+	Kind      string  `json:"kind"`
+	Name      *string `json:"name"`
+	Color     *string `json:"color"`
+}
+
+func (q *Queries) SelectManualEntries(ctx context.Context, arg SelectManualEntriesParams) ([]SelectManualEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, selectManualEntries, arg.EndAt, arg.StartAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SelectManualEntriesRow
+	for rows.Next() {
+		var i SelectManualEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Note,
+			&i.StartAt,
+			&i.EndAt,
+			&i.Kind,
+			&i.Name,
+			&i.Color,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectManualEntry = `-- name: SelectManualEntry :one
+select id, project_id, note, start_at, end_at, kind
+from manual_entry
+where id = ?1
+`
+
+func (q *Queries) SelectManualEntry(ctx context.Context, id int64) (ManualEntry, error) {
+	row := q.db.QueryRowContext(ctx, selectManualEntry, id)
+	var i ManualEntry
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Note,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const selectPendingGaps = `-- name: SelectPendingGaps :many
+select id, start_at, end_at, kind, resolution, manual_entry_id
+from unaccounted_gap
+where resolution = ''
+order by start_at
+`
+
+func (q *Queries) SelectPendingGaps(ctx context.Context) ([]UnaccountedGap, error) {
+	rows, err := q.db.QueryContext(ctx, selectPendingGaps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UnaccountedGap
+	for rows.Next() {
+		var i UnaccountedGap
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartAt,
+			&i.EndAt,
+			&i.Kind,
+			&i.Resolution,
+			&i.ManualEntryID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectProject = `-- name: SelectProject :one
+select id, name, color
+from project
+where id = ?1
+`
+
+func (q *Queries) SelectProject(ctx context.Context, id int64) (Project, error) {
+	row := q.db.QueryRowContext(ctx, selectProject, id)
+	var i Project
+	err := row.Scan(&i.ID, &i.Name, &i.Color)
+	return i, err
+}
+
+const selectProjectByName = `-- name: SelectProjectByName :one
+select id, name, color
+from project
+where lower(name) = lower(?1)
+`
+
+func (q *Queries) SelectProjectByName(ctx context.Context, name string) (Project, error) {
+	row := q.db.QueryRowContext(ctx, selectProjectByName, name)
+	var i Project
+	err := row.Scan(&i.ID, &i.Name, &i.Color)
+	return i, err
+}
+
+const selectProjectManualEntryIDs = `-- name: SelectProjectManualEntryIDs :many
+select id
+from manual_entry
+where project_id = ?1
+order by id
+`
+
+func (q *Queries) SelectProjectManualEntryIDs(ctx context.Context, projectID *int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, selectProjectManualEntryIDs, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectProjectPinnedSpanIDs = `-- name: SelectProjectPinnedSpanIDs :many
+select span_id
+from span_pin
+where project_id = ?1
+order by span_id
+`
+
+func (q *Queries) SelectProjectPinnedSpanIDs(ctx context.Context, projectID *int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, selectProjectPinnedSpanIDs, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var span_id int64
+		if err := rows.Scan(&span_id); err != nil {
+			return nil, err
+		}
+		items = append(items, span_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectProjectRule = `-- name: SelectProjectRule :one
+select id, pattern, project_id, is_active
+from project_rule
+where id = ?1
+`
+
+func (q *Queries) SelectProjectRule(ctx context.Context, id int64) (ProjectRule, error) {
+	row := q.db.QueryRowContext(ctx, selectProjectRule, id)
+	var i ProjectRule
+	err := row.Scan(
+		&i.ID,
+		&i.Pattern,
+		&i.ProjectID,
+		&i.IsActive,
+	)
+	return i, err
+}
+
+const selectProjectRuleIDs = `-- name: SelectProjectRuleIDs :many
+select id
+from project_rule
+where project_id = ?1
+order by id
+`
+
+func (q *Queries) SelectProjectRuleIDs(ctx context.Context, projectID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, selectProjectRuleIDs, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectProjectRules = `-- name: SelectProjectRules :many
+select pr.id, pr.pattern, pr.project_id, pr.is_active, p.name, p.color
+from project_rule pr
+         join project p on pr.project_id = p.id
+order by p.id, pr.id
+`
+
+type SelectProjectRulesRow struct {
+	ID        int64  `json:"id"`
+	Pattern   string `json:"pattern"`
+	ProjectID int64  `json:"project_id"`
+	IsActive  bool   `json:"is_active"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+}
+
+func (q *Queries) SelectProjectRules(ctx context.Context) ([]SelectProjectRulesRow, error) {
+	rows, err := q.db.QueryContext(ctx, selectProjectRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SelectProjectRulesRow
+	for rows.Next() {
+		var i SelectProjectRulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Pattern,
+			&i.ProjectID,
+			&i.IsActive,
+			&i.Name,
+			&i.Color,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectProjects = `-- name: SelectProjects :many
+select id, name, color
+from project
+order by id
+`
+
+func (q *Queries) SelectProjects(ctx context.Context) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, selectProjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(&i.ID, &i.Name, &i.Color); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectRunningManualEntry = `-- name: SelectRunningManualEntry :one
+select m.id, m.project_id, m.note, m.start_at, m.end_at, p.name, p.color
+from manual_entry m
+         left join project p on m.project_id = p.id
+where m.end_at = 0
+`
+
+type SelectRunningManualEntryRow struct {
+	ID        int64   `json:"id"`
+	ProjectID *int64  `json:"project_id"`
+	Note      string  `json:"note"`
+	StartAt   int64   `json:"start_at"`
+	EndAt     int64   `json:"end_at"`
+	Name      *string `json:"name"`
+	Color     *string `json:"color"`
+}
+
+func (q *Queries) SelectRunningManualEntry(ctx context.Context) (SelectRunningManualEntryRow, error) {
+	row := q.db.QueryRowContext(ctx, selectRunningManualEntry)
+	var i SelectRunningManualEntryRow
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Note,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Name,
+		&i.Color,
+	)
+	return i, err
+}
+
+const selectSpan = `-- name: SelectSpan :one
+select id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
+from span
+where id = ?1
+`
+
+func (q *Queries) SelectSpan(ctx context.Context, id int64) (Span, error) {
+	row := q.db.QueryRowContext(ctx, selectSpan, id)
+	var i Span
+	err := row.Scan(
+		&i.ID,
+		&i.AppName,
+		&i.WindowTitle,
+		&i.StartAt,
+		&i.EndAt,
+		&i.AppID,
+		&i.Pid,
+		&i.Display,
+		&i.RawWindowTitle,
+	)
+	return i, err
+}
+
+const selectSpanAttributes = `-- name: SelectSpanAttributes :many
+select span_attribute.span_id, span_attribute.key, span_attribute.value, span_attribute.type
+from span_attribute
+         join span on span.id = span_attribute.span_id
+where span.start_at > ?1
+  and span.end_at < ?2
+`
+
+type SelectSpanAttributesParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+func (q *Queries) SelectSpanAttributes(ctx context.Context, arg SelectSpanAttributesParams) ([]SpanAttribute, error) {
+	rows, err := q.db.QueryContext(ctx, selectSpanAttributes, arg.StartAt, arg.EndAt)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SelectCategoryRulesRow
+	var items []SpanAttribute
 	for rows.Next() {
-		var i SelectCategoryRulesRow
+		var i SpanAttribute
 		if err := rows.Scan(
-			&i.ID,
-			&i.Pattern,
-			&i.CategoryID,
-			&i.IsActive,
-			&i.Name,
-			&i.Color,
+			&i.SpanID,
+			&i.Key,
+			&i.Value,
+			&i.Type,
 		); err != nil {
 			return nil, err
 		}
@@ -259,35 +1686,27 @@ func (q *Queries) SelectCategoryRules(ctx context.Context) ([]SelectCategoryRule
 	return items, nil
 }
 
-const selectCategorySpans = `-- name: SelectCategorySpans :many
-with rule as ( select id, pattern, category_id, is_active from category_rule where category_rule.id = ?2 )
-select id, app_name, window_title, start_at, end_at
-from span
-where regexp_like(app_name, rule.pattern)
-   or regexp_like(window_title, rule.pattern)
-limit ?1
+const selectSpanAttributesBySpan = `-- name: SelectSpanAttributesBySpan :many
+select span_id, key, value, type
+from span_attribute
+where span_id = ?1
+order by key
 `
 
-type SelectCategorySpansParams struct {
-	Limit      int64 `json:"limit"`
-	CategoryID int64 `json:"category_id"`
-}
-
-func (q *Queries) SelectCategorySpans(ctx context.Context, arg SelectCategorySpansParams) ([]Span, error) {
-	rows, err := q.db.QueryContext(ctx, selectCategorySpans, arg.Limit, arg.CategoryID)
+func (q *Queries) SelectSpanAttributesBySpan(ctx context.Context, spanID int64) ([]SpanAttribute, error) {
+	rows, err := q.db.QueryContext(ctx, selectSpanAttributesBySpan, spanID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Span
+	var items []SpanAttribute
 	for rows.Next() {
-		var i Span
+		var i SpanAttribute
 		if err := rows.Scan(
-			&i.ID,
-			&i.AppName,
-			&i.WindowTitle,
-			&i.StartAt,
-			&i.EndAt,
+			&i.SpanID,
+			&i.Key,
+			&i.Value,
+			&i.Type,
 		); err != nil {
 			return nil, err
 		}
@@ -302,62 +1721,53 @@ func (q *Queries) SelectCategorySpans(ctx context.Context, arg SelectCategorySpa
 	return items, nil
 }
 
-const selectLatestSpan = `-- name: SelectLatestSpan :one
-
-select id, app_name, window_title, start_at, end_at
-from span
-order by start_at desc
-limit 1
+const selectSpanBrowserTab = `-- name: SelectSpanBrowserTab :one
+select span_id, url, domain, title, incognito
+from span_browser_tab
+where span_id = ?1
 `
 
-// ---------------------------------------
-// Spans
-// ---------------------------------------
-func (q *Queries) SelectLatestSpan(ctx context.Context) (Span, error) {
-	row := q.db.QueryRowContext(ctx, selectLatestSpan)
-	var i Span
+func (q *Queries) SelectSpanBrowserTab(ctx context.Context, spanID int64) (SpanBrowserTab, error) {
+	row := q.db.QueryRowContext(ctx, selectSpanBrowserTab, spanID)
+	var i SpanBrowserTab
 	err := row.Scan(
-		&i.ID,
-		&i.AppName,
-		&i.WindowTitle,
-		&i.StartAt,
-		&i.EndAt,
+		&i.SpanID,
+		&i.Url,
+		&i.Domain,
+		&i.Title,
+		&i.Incognito,
 	)
 	return i, err
 }
 
-const selectProjectRules = `-- name: SelectProjectRules :many
-select pr.id, pr.pattern, pr.project_id, pr.is_active, p.name, p.color
-from project_rule pr
-         join project p on pr.project_id = p.id
-order by p.id, pr.id
+const selectSpanBrowserTabs = `-- name: SelectSpanBrowserTabs :many
+select span_browser_tab.span_id, span_browser_tab.url, span_browser_tab.domain, span_browser_tab.title, span_browser_tab.incognito
+from span_browser_tab
+         join span on span.id = span_browser_tab.span_id
+where span.start_at > ?1
+  and span.end_at < ?2
 `
 
-type SelectProjectRulesRow struct {
-	ID        int64  `json:"id"`
-	Pattern   string `json:"pattern"`
-	ProjectID int64  `json:"project_id"`
-	IsActive  bool   `json:"is_active"`
-	Name      string `json:"name"`
-	Color     string `json:"color"`
+type SelectSpanBrowserTabsParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
 }
 
-func (q *Queries) SelectProjectRules(ctx context.Context) ([]SelectProjectRulesRow, error) {
-	rows, err := q.db.QueryContext(ctx, selectProjectRules)
+func (q *Queries) SelectSpanBrowserTabs(ctx context.Context, arg SelectSpanBrowserTabsParams) ([]SpanBrowserTab, error) {
+	rows, err := q.db.QueryContext(ctx, selectSpanBrowserTabs, arg.StartAt, arg.EndAt)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SelectProjectRulesRow
+	var items []SpanBrowserTab
 	for rows.Next() {
-		var i SelectProjectRulesRow
+		var i SpanBrowserTab
 		if err := rows.Scan(
-			&i.ID,
-			&i.Pattern,
-			&i.ProjectID,
-			&i.IsActive,
-			&i.Name,
-			&i.Color,
+			&i.SpanID,
+			&i.Url,
+			&i.Domain,
+			&i.Title,
+			&i.Incognito,
 		); err != nil {
 			return nil, err
 		}
@@ -372,22 +1782,42 @@ func (q *Queries) SelectProjectRules(ctx context.Context) ([]SelectProjectRulesR
 	return items, nil
 }
 
-const selectProjects = `-- name: SelectProjects :many
-select id, name, color
-from project
-order by id
+const selectSpanPin = `-- name: SelectSpanPin :one
+select span_id, project_id, category_id
+from span_pin
+where span_id = ?1
 `
 
-func (q *Queries) SelectProjects(ctx context.Context) ([]Project, error) {
-	rows, err := q.db.QueryContext(ctx, selectProjects)
+func (q *Queries) SelectSpanPin(ctx context.Context, spanID int64) (SpanPin, error) {
+	row := q.db.QueryRowContext(ctx, selectSpanPin, spanID)
+	var i SpanPin
+	err := row.Scan(&i.SpanID, &i.ProjectID, &i.CategoryID)
+	return i, err
+}
+
+const selectSpanPins = `-- name: SelectSpanPins :many
+select span_pin.span_id, span_pin.project_id, span_pin.category_id
+from span_pin
+         join span on span.id = span_pin.span_id
+where span.start_at > ?1
+  and span.end_at < ?2
+`
+
+type SelectSpanPinsParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+func (q *Queries) SelectSpanPins(ctx context.Context, arg SelectSpanPinsParams) ([]SpanPin, error) {
+	rows, err := q.db.QueryContext(ctx, selectSpanPins, arg.StartAt, arg.EndAt)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Project
+	var items []SpanPin
 	for rows.Next() {
-		var i Project
-		if err := rows.Scan(&i.ID, &i.Name, &i.Color); err != nil {
+		var i SpanPin
+		if err := rows.Scan(&i.SpanID, &i.ProjectID, &i.CategoryID); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -402,7 +1832,7 @@ func (q *Queries) SelectProjects(ctx context.Context) ([]Project, error) {
 }
 
 const selectSpans = `-- name: SelectSpans :many
-select id, app_name, window_title, start_at, end_at
+select id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
 from span
 where start_at > ?1
   and end_at < ?2
@@ -428,6 +1858,117 @@ func (q *Queries) SelectSpans(ctx context.Context, arg SelectSpansParams) ([]Spa
 			&i.WindowTitle,
 			&i.StartAt,
 			&i.EndAt,
+			&i.AppID,
+			&i.Pid,
+			&i.Display,
+			&i.RawWindowTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectSpansBetween = `-- name: SelectSpansBetween :many
+select id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
+from span
+where start_at < ?1
+  and end_at > ?2
+order by start_at
+`
+
+type SelectSpansBetweenParams struct {
+	EndAt   int64 `json:"end_at"`
+	StartAt int64 `json:"start_at"`
+}
+
+func (q *Queries) SelectSpansBetween(ctx context.Context, arg SelectSpansBetweenParams) ([]Span, error) {
+	rows, err := q.db.QueryContext(ctx, selectSpansBetween, arg.EndAt, arg.StartAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Span
+	for rows.Next() {
+		var i Span
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppName,
+			&i.WindowTitle,
+			&i.StartAt,
+			&i.EndAt,
+			&i.AppID,
+			&i.Pid,
+			&i.Display,
+			&i.RawWindowTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectTerminalContext = `-- name: SelectTerminalContext :one
+select span_id, cwd, git_root, command, source
+from terminal_context
+where span_id = ?1
+`
+
+func (q *Queries) SelectTerminalContext(ctx context.Context, spanID int64) (TerminalContext, error) {
+	row := q.db.QueryRowContext(ctx, selectTerminalContext, spanID)
+	var i TerminalContext
+	err := row.Scan(
+		&i.SpanID,
+		&i.Cwd,
+		&i.GitRoot,
+		&i.Command,
+		&i.Source,
+	)
+	return i, err
+}
+
+const selectTerminalContexts = `-- name: SelectTerminalContexts :many
+select terminal_context.span_id, terminal_context.cwd, terminal_context.git_root, terminal_context.command, terminal_context.source
+from terminal_context
+         join span on span.id = terminal_context.span_id
+where span.start_at > ?1
+  and span.end_at < ?2
+`
+
+type SelectTerminalContextsParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+func (q *Queries) SelectTerminalContexts(ctx context.Context, arg SelectTerminalContextsParams) ([]TerminalContext, error) {
+	rows, err := q.db.QueryContext(ctx, selectTerminalContexts, arg.StartAt, arg.EndAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TerminalContext
+	for rows.Next() {
+		var i TerminalContext
+		if err := rows.Scan(
+			&i.SpanID,
+			&i.Cwd,
+			&i.GitRoot,
+			&i.Command,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -442,6 +1983,72 @@ func (q *Queries) SelectSpans(ctx context.Context, arg SelectSpansParams) ([]Spa
 	return items, nil
 }
 
+const selectUnaccountedGap = `-- name: SelectUnaccountedGap :one
+select id, start_at, end_at, kind, resolution, manual_entry_id
+from unaccounted_gap
+where id = ?1
+`
+
+func (q *Queries) SelectUnaccountedGap(ctx context.Context, id int64) (UnaccountedGap, error) {
+	row := q.db.QueryRowContext(ctx, selectUnaccountedGap, id)
+	var i UnaccountedGap
+	err := row.Scan(
+		&i.ID,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Kind,
+		&i.Resolution,
+		&i.ManualEntryID,
+	)
+	return i, err
+}
+
+const stopManualEntry = `-- name: StopManualEntry :one
+update manual_entry
+set end_at = ?1
+where end_at = 0
+returning id, project_id, note, start_at, end_at, kind
+`
+
+func (q *Queries) StopManualEntry(ctx context.Context, endAt int64) (ManualEntry, error) {
+	row := q.db.QueryRowContext(ctx, stopManualEntry, endAt)
+	var i ManualEntry
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Note,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const updateAwaySpan = `-- name: UpdateAwaySpan :one
+update away_span
+set end_at = ?1
+where id = ?2
+returning id, kind, reason, start_at, end_at
+`
+
+type UpdateAwaySpanParams struct {
+	EndAt int64 `json:"end_at"`
+	ID    int64 `json:"id"`
+}
+
+func (q *Queries) UpdateAwaySpan(ctx context.Context, arg UpdateAwaySpanParams) (AwaySpan, error) {
+	row := q.db.QueryRowContext(ctx, updateAwaySpan, arg.EndAt, arg.ID)
+	var i AwaySpan
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Reason,
+		&i.StartAt,
+		&i.EndAt,
+	)
+	return i, err
+}
+
 const updateCategory = `-- name: UpdateCategory :one
 update category
 set name  = ?1,
@@ -554,7 +2161,7 @@ const updateSpan = `-- name: UpdateSpan :one
 update span
 set end_at = ?1
 where id = ?2
-returning id, app_name, window_title, start_at, end_at
+returning id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
 `
 
 type UpdateSpanParams struct {
@@ -571,6 +2178,159 @@ func (q *Queries) UpdateSpan(ctx context.Context, arg UpdateSpanParams) (Span, e
 		&i.WindowTitle,
 		&i.StartAt,
 		&i.EndAt,
+		&i.AppID,
+		&i.Pid,
+		&i.Display,
+		&i.RawWindowTitle,
 	)
 	return i, err
 }
+
+const updateSpanBounds = `-- name: UpdateSpanBounds :one
+update span
+set start_at = ?1,
+    end_at   = ?2
+where id = ?3
+returning id, app_name, window_title, start_at, end_at, app_id, pid, display, raw_window_title
+`
+
+type UpdateSpanBoundsParams struct {
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+	ID      int64 `json:"id"`
+}
+
+func (q *Queries) UpdateSpanBounds(ctx context.Context, arg UpdateSpanBoundsParams) (Span, error) {
+	row := q.db.QueryRowContext(ctx, updateSpanBounds, arg.StartAt, arg.EndAt, arg.ID)
+	var i Span
+	err := row.Scan(
+		&i.ID,
+		&i.AppName,
+		&i.WindowTitle,
+		&i.StartAt,
+		&i.EndAt,
+		&i.AppID,
+		&i.Pid,
+		&i.Display,
+		&i.RawWindowTitle,
+	)
+	return i, err
+}
+
+const upsertSpanAttribute = `-- name: UpsertSpanAttribute :exec
+
+insert into span_attribute(span_id, key, value, type)
+values (?1, ?2, ?3, ?4)
+on conflict (span_id, key) do update
+    set value = excluded.value,
+        type  = excluded.type
+`
+
+type UpsertSpanAttributeParams struct {
+	SpanID int64  `json:"span_id"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Type   string `json:"type"`
+}
+
+// ---------------------------------------
+// Span Attributes
+// ---------------------------------------
+func (q *Queries) UpsertSpanAttribute(ctx context.Context, arg UpsertSpanAttributeParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSpanAttribute,
+		arg.SpanID,
+		arg.Key,
+		arg.Value,
+		arg.Type,
+	)
+	return err
+}
+
+const upsertSpanBrowserTab = `-- name: UpsertSpanBrowserTab :exec
+
+insert into span_browser_tab(span_id, url, domain, title, incognito)
+values (?1, ?2, ?3, ?4, ?5)
+on conflict (span_id) do update
+    set url       = excluded.url,
+        domain    = excluded.domain,
+        title     = excluded.title,
+        incognito = excluded.incognito
+`
+
+type UpsertSpanBrowserTabParams struct {
+	SpanID    int64  `json:"span_id"`
+	Url       string `json:"url"`
+	Domain    string `json:"domain"`
+	Title     string `json:"title"`
+	Incognito bool   `json:"incognito"`
+}
+
+// ---------------------------------------
+// Browser Tabs
+// ---------------------------------------
+func (q *Queries) UpsertSpanBrowserTab(ctx context.Context, arg UpsertSpanBrowserTabParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSpanBrowserTab,
+		arg.SpanID,
+		arg.Url,
+		arg.Domain,
+		arg.Title,
+		arg.Incognito,
+	)
+	return err
+}
+
+const upsertSpanPin = `-- name: UpsertSpanPin :exec
+
+insert into span_pin(span_id, project_id, category_id)
+values (?1, ?2, ?3)
+on conflict (span_id) do update
+    set project_id  = coalesce(excluded.project_id, span_pin.project_id),
+        category_id = coalesce(excluded.category_id, span_pin.category_id)
+`
+
+type UpsertSpanPinParams struct {
+	SpanID     int64  `json:"span_id"`
+	ProjectID  *int64 `json:"project_id"`
+	CategoryID *int64 `json:"category_id"`
+}
+
+// ---------------------------------------
+// Span Pins
+// ---------------------------------------
+func (q *Queries) UpsertSpanPin(ctx context.Context, arg UpsertSpanPinParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSpanPin, arg.SpanID, arg.ProjectID, arg.CategoryID)
+	return err
+}
+
+const upsertTerminalContext = `-- name: UpsertTerminalContext :exec
+
+insert into terminal_context(span_id, cwd, git_root, command, source)
+values (?1, ?2, ?3, ?4, ?5)
+on conflict (span_id) do update
+    set cwd      = excluded.cwd,
+        git_root = excluded.git_root,
+        command  = excluded.command,
+        source   = excluded.source
+`
+
+type UpsertTerminalContextParams struct {
+	SpanID  int64  `json:"span_id"`
+	Cwd     string `json:"cwd"`
+	GitRoot string `json:"git_root"`
+	Command string `json:"command"`
+	Source  string `json:"source"`
+}
+
+// ---------------------------------------
+// Terminal Context
+// ---------------------------------------
+func (q *Queries) UpsertTerminalContext(ctx context.Context, arg UpsertTerminalContextParams) error {
+	_, err := q.db.ExecContext(ctx, upsertTerminalContext,
+		arg.SpanID,
+		arg.Cwd,
+		arg.GitRoot,
+		arg.Command,
+		arg.Source,
+	)
+	return err
+}
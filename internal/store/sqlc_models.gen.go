@@ -4,6 +4,24 @@
 
 package store
 
+type AuditLog struct {
+	ID     int64  `json:"id"`
+	At     int64  `json:"at"`
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Undoes *int64 `json:"undoes"`
+}
+
+type AwaySpan struct {
+	ID      int64  `json:"id"`
+	Kind    string `json:"kind"`
+	Reason  string `json:"reason"`
+	StartAt int64  `json:"start_at"`
+	EndAt   int64  `json:"end_at"`
+}
+
 type Category struct {
 	ID    int64  `json:"id"`
 	Name  string `json:"name"`
@@ -17,6 +35,28 @@ type CategoryRule struct {
 	IsActive   bool   `json:"is_active"`
 }
 
+type Heartbeat struct {
+	ID        int64  `json:"id"`
+	Time      int64  `json:"time"`
+	Entity    string `json:"entity"`
+	Type      string `json:"type"`
+	Category  string `json:"category"`
+	Project   string `json:"project"`
+	Branch    string `json:"branch"`
+	Language  string `json:"language"`
+	IsWrite   bool   `json:"is_write"`
+	UserAgent string `json:"user_agent"`
+}
+
+type ManualEntry struct {
+	ID        int64  `json:"id"`
+	ProjectID *int64 `json:"project_id"`
+	Note      string `json:"note"`
+	StartAt   int64  `json:"start_at"`
+	EndAt     int64  `json:"end_at"`
+	Kind      string `json:"kind"`
+}
+
 type Project struct {
 	ID    int64  `json:"id"`
 	Name  string `json:"name"`
@@ -31,9 +71,51 @@ type ProjectRule struct {
 }
 
 type Span struct {
-	ID          int64  `json:"id"`
-	AppName     string `json:"app_name"`
-	WindowTitle string `json:"window_title"`
-	StartAt     int64  `json:"start_at"`
-	EndAt       int64  `json:"end_at"`
+	ID             int64  `json:"id"`
+	AppName        string `json:"app_name"`
+	WindowTitle    string `json:"window_title"`
+	StartAt        int64  `json:"start_at"`
+	EndAt          int64  `json:"end_at"`
+	AppID          string `json:"app_id"`
+	Pid            int64  `json:"pid"`
+	Display        string `json:"display"`
+	RawWindowTitle string `json:"raw_window_title"`
+}
+
+type SpanAttribute struct {
+	SpanID int64  `json:"span_id"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Type   string `json:"type"`
+}
+
+type SpanBrowserTab struct {
+	SpanID    int64  `json:"span_id"`
+	Url       string `json:"url"`
+	Domain    string `json:"domain"`
+	Title     string `json:"title"`
+	Incognito bool   `json:"incognito"`
+}
+
+type SpanPin struct {
+	SpanID     int64  `json:"span_id"`
+	ProjectID  *int64 `json:"project_id"`
+	CategoryID *int64 `json:"category_id"`
+}
+
+type TerminalContext struct {
+	SpanID  int64  `json:"span_id"`
+	Cwd     string `json:"cwd"`
+	GitRoot string `json:"git_root"`
+	Command string `json:"command"`
+	Source  string `json:"source"`
+}
+
+type UnaccountedGap struct {
+	ID            int64  `json:"id"`
+	StartAt       int64  `json:"start_at"`
+	EndAt         int64  `json:"end_at"`
+	Kind          string `json:"kind"`
+	Resolution    string `json:"resolution"`
+	ManualEntryID *int64 `json:"manual_entry_id"`
 }
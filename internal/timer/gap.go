@@ -0,0 +1,91 @@
+package timer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Gap resolutions, besides the manual entry kinds
+const (
+	ResolutionDiscard = "discard" // the away time stays as it was recorded
+)
+
+// ErrGapResolved is returned by ResolveGap for a gap that was already resolved.
+var ErrGapResolved = errors.New("gap already resolved")
+
+// Resolution says what the user was doing during an unaccounted gap.
+type Resolution struct {
+	Kind      string `json:"kind"`                 // project | break | meeting | discard
+	ProjectID int64  `json:"project_id,omitempty"` // for kind project
+	Note      string `json:"note,omitempty"`
+}
+
+// ResolveGap records a manual entry covering the gap, unless the resolution discards it, and marks the gap resolved.
+// Both happen in one transaction, so a gap resolved concurrently doesn't leave a second manual entry behind.
+func ResolveGap(ctx context.Context, db *store.Queries, gapID int64, res Resolution) (store.UnaccountedGap, error) {
+	var resolved store.UnaccountedGap
+	err := db.InTx(ctx, func(q *store.Queries) error {
+		gap, err := q.SelectUnaccountedGap(ctx, gapID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("gap %d not found", gapID)
+		}
+		if err != nil {
+			return fmt.Errorf("select unaccounted gap: %w", err)
+		}
+		if gap.Resolution != "" {
+			return ErrGapResolved
+		}
+
+		params := store.InsertManualEntryParams{
+			Kind:    res.Kind,
+			Note:    res.Note,
+			StartAt: gap.StartAt,
+			EndAt:   gap.EndAt,
+		}
+		switch res.Kind {
+		case KindProject:
+			if res.ProjectID == 0 {
+				return errors.New("a project is needed to assign a gap to it")
+			}
+			if _, err := q.SelectProject(ctx, res.ProjectID); errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("project %d not found", res.ProjectID)
+			} else if err != nil {
+				return fmt.Errorf("select project: %w", err)
+			}
+			params.ProjectID = &res.ProjectID
+		case KindBreak, KindMeeting:
+		case ResolutionDiscard:
+			resolved, err = resolve(ctx, q, gap.ID, res.Kind, nil)
+			return err
+		default:
+			return fmt.Errorf("resolution must be project, break, meeting or discard, got %q", res.Kind)
+		}
+
+		entry, err := q.InsertManualEntry(ctx, params)
+		if err != nil {
+			return fmt.Errorf("insert manual entry: %w", err)
+		}
+		resolved, err = resolve(ctx, q, gap.ID, res.Kind, &entry.ID)
+		return err
+	})
+	return resolved, err
+}
+
+func resolve(ctx context.Context, db *store.Queries, gapID int64, resolution string, manualEntryID *int64) (store.UnaccountedGap, error) {
+	gap, err := db.ResolveUnaccountedGap(ctx, store.ResolveUnaccountedGapParams{
+		Resolution:    resolution,
+		ManualEntryID: manualEntryID,
+		ID:            gapID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return gap, ErrGapResolved // resolved concurrently
+	}
+	if err != nil {
+		return gap, fmt.Errorf("resolve unaccounted gap: %w", err)
+	}
+	return gap, nil
+}
@@ -0,0 +1,63 @@
+// Package timer records manual time entries (a phone call, whiteboarding, pairing on another machine), which take
+// precedence over the automatically tracked spans they overlap. Entries come from timers, whose running timer is
+// stored in the database so it keeps running across daemon restarts, and from resolving unaccounted gaps.
+package timer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Manual entry kinds
+const (
+	KindProject = "project" // time spent on a project
+	KindBreak   = "break"   // not working, shown as away time
+	KindMeeting = "meeting" // a meeting not tied to a project
+)
+
+// ErrNotRunning is returned by Stop when no timer is running.
+var ErrNotRunning = errors.New("no timer running")
+
+// Start starts a timer for the project at now. A running timer is stopped first, in the same transaction, and returned
+// as stopped, which has a zero ID when no timer was running.
+func Start(ctx context.Context, db *store.Queries, projectID int64, note string, now time.Time) (started, stopped store.ManualEntry, err error) {
+	err = db.InTx(ctx, func(q *store.Queries) error {
+		var err error
+		stopped, err = Stop(ctx, q, now)
+		if err != nil && !errors.Is(err, ErrNotRunning) {
+			return err
+		}
+
+		started, err = q.InsertManualEntry(ctx, store.InsertManualEntryParams{
+			Kind:      KindProject,
+			ProjectID: &projectID,
+			Note:      note,
+			StartAt:   now.Unix(),
+		})
+		if err != nil {
+			return fmt.Errorf("insert manual entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return store.ManualEntry{}, store.ManualEntry{}, err
+	}
+	return started, stopped, nil
+}
+
+// Stop stops the running timer at now and returns its entry.
+func Stop(ctx context.Context, db *store.Queries, now time.Time) (store.ManualEntry, error) {
+	entry, err := db.StopManualEntry(ctx, now.Unix())
+	if errors.Is(err, sql.ErrNoRows) {
+		return entry, ErrNotRunning
+	}
+	if err != nil {
+		return entry, fmt.Errorf("stop manual entry: %w", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,80 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Attribute value types, values are always stored as text
+const (
+	AttrTypeString = "string"
+	AttrTypeNumber = "number"
+	AttrTypeBool   = "bool"
+)
+
+// Attribute keys filled by the integrations
+const (
+	AttrURL       = "url"
+	AttrDomain    = "domain"
+	AttrIncognito = "incognito"
+	AttrCwd       = "cwd"
+	AttrGitRoot   = "git_root"
+	AttrGitBranch = "git_branch"
+	AttrCommand   = "command"
+)
+
+// Attribute is a typed key/value pair stored with a span. Title parsers, the browser extension and the shell hook
+// all record their context as attributes, so rules and filters can use one model.
+//
+// Attributes are the source of truth for span context. The browser extension and shell hook still write
+// span_browser_tab and terminal_context as well, for the timeline and audit code reading them, until those move to
+// attributes and the tables are dropped. Migration 010 copied the rows recorded before attributes existed.
+type Attribute struct {
+	Key   string
+	Value string
+	Type  string
+}
+
+// StringAttr returns a string attribute.
+func StringAttr(key, value string) Attribute {
+	return Attribute{Key: key, Value: value, Type: AttrTypeString}
+}
+
+// BoolAttr returns a bool attribute.
+func BoolAttr(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: strconv.FormatBool(value), Type: AttrTypeBool}
+}
+
+// AttributeValue returns the stored text of an attribute as its type: float64 for numbers, bool for bools and string
+// otherwise (including values that don't parse as their type).
+func AttributeValue(value, typ string) any {
+	switch typ {
+	case AttrTypeNumber:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case AttrTypeBool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// setAttributes stores attrs with the span, replacing earlier values of the same keys.
+func (t *Tracker) setAttributes(ctx context.Context, spanID int64, attrs ...Attribute) error {
+	for _, attr := range attrs {
+		if err := t.db.UpsertSpanAttribute(ctx, store.UpsertSpanAttributeParams{
+			SpanID: spanID,
+			Key:    attr.Key,
+			Value:  attr.Value,
+			Type:   attr.Type,
+		}); err != nil {
+			return fmt.Errorf("upsert span attribute %s: %w", attr.Key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Away span kinds
+const (
+	AwayIdle    = "idle"    // no input for longer than the idle threshold
+	AwayAsleep  = "asleep"  // no polls at all, the machine was asleep or off
+	AwayLocked  = "locked"  // the screen was locked
+	AwayAway    = "away"    // the daemon wasn't running
+	AwayPaused  = "paused"  // tracking was paused from the control socket
+	AwayPrivate = "private" // the focused window was excluded by the privacy settings
+)
+
+// Reasons tracking stopped
+const (
+	ReasonIdleThreshold = "idle_threshold"
+	ReasonStaleGap      = "stale_gap"
+	ReasonScreenLocked  = "screen_locked"
+	ReasonDaemonStopped = "daemon_stopped"
+	ReasonPaused        = "user_paused"
+	ReasonIgnoredApp    = "ignored_app"
+	ReasonPrivateWindow = "private_window"
+)
+
+// LockSource is implemented by idle sources that can tell whether the screen is locked.
+type LockSource interface {
+	IsScreenLocked() (bool, error)
+}
+
+// latestActivity returns the most recent span and away span (either may be empty).
+func (t *Tracker) latestActivity(ctx context.Context) (store.Span, store.AwaySpan, error) {
+	latestSpan, err := t.db.SelectLatestSpan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return store.Span{}, store.AwaySpan{}, fmt.Errorf("select latest span: %w", err)
+	}
+	latestAway, err := t.db.SelectLatestAwaySpan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return store.Span{}, store.AwaySpan{}, fmt.Errorf("select latest away span: %w", err)
+	}
+	return latestSpan, latestAway, nil
+}
+
+// recordGap records the time since the last span or away span when it exceeds the stale threshold, i.e. when no
+// polls happened because the machine was asleep. If the daemon recorded that it stopped, that record is extended
+// instead.
+func (t *Tracker) recordGap(ctx context.Context, now int64) error {
+	latestSpan, latestAway, err := t.latestActivity(ctx)
+	if err != nil {
+		return err
+	}
+	if latestSpan.ID == 0 && latestAway.ID == 0 {
+		return nil // cold start
+	}
+
+	awayIsLatest := latestAway.ID > 0 && latestAway.EndAt >= latestSpan.EndAt
+
+	if awayIsLatest && latestAway.Reason == ReasonDaemonStopped {
+		if _, err := t.db.UpdateAwaySpan(ctx, store.UpdateAwaySpanParams{
+			ID:    latestAway.ID,
+			EndAt: now,
+		}); err != nil {
+			return fmt.Errorf("update away span: %w", err)
+		}
+		// Once tracking resumes this record is no longer the latest, so it won't be extended again
+		return nil
+	}
+
+	lastSeen := latestSpan.EndAt
+	if awayIsLatest {
+		lastSeen = latestAway.EndAt
+	}
+	if now-lastSeen <= int64(t.staleThreshold.Seconds()) {
+		return nil
+	}
+
+	if _, err := t.db.InsertAwaySpan(ctx, store.InsertAwaySpanParams{
+		Kind:    AwayAsleep,
+		Reason:  ReasonStaleGap,
+		StartAt: lastSeen,
+		EndAt:   now,
+	}); err != nil {
+		return fmt.Errorf("insert away span: %w", err)
+	}
+
+	slog.Debug("Recorded stale gap", "from", lastSeen, "to", now)
+
+	return nil
+}
+
+// trimToLastInput ends the latest span at lastInput if it ran past it and wasn't edited since, so the minutes before
+// the idle threshold was crossed aren't counted towards the focused window. It returns the time the idle away span
+// should start at, which is never before the end of the previous activity.
+func (t *Tracker) trimToLastInput(ctx context.Context, lastInput int64) (int64, error) {
+	latestSpan, latestAway, err := t.latestActivity(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if latestAway.ID > 0 && latestAway.EndAt >= latestSpan.EndAt {
+		return max(lastInput, latestAway.EndAt), nil
+	}
+	// spans edited since the tracker wrote them are left as they are
+	if latestSpan.ID == 0 || latestSpan.EndAt <= lastInput || !t.owns(latestSpan) {
+		return max(lastInput, latestSpan.EndAt), nil
+	}
+
+	endAt := max(lastInput, latestSpan.StartAt)
+	trimmed, err := t.db.UpdateSpan(ctx, store.UpdateSpanParams{
+		ID:    latestSpan.ID,
+		EndAt: endAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("update span: %w", err)
+	}
+	t.openSpan = trimmed
+
+	slog.Debug("Trimmed span to last input", "app", latestSpan.AppName, "from", latestSpan.EndAt, "to", endAt)
+
+	return endAt, nil
+}
+
+// saveAway extends the current away span if it has the same kind and no span was recorded since, otherwise a new
+// away span starting at startAt is created.
+func (t *Tracker) saveAway(ctx context.Context, kind, reason string, startAt, now int64) error {
+	latestSpan, latestAway, err := t.latestActivity(ctx)
+	if err != nil {
+		return err
+	}
+
+	awayIsLatest := latestAway.ID > 0 && latestAway.EndAt >= latestSpan.EndAt
+	awayStale := now-latestAway.EndAt > int64(t.staleThreshold.Seconds())
+
+	if awayIsLatest && latestAway.Kind == kind && !awayStale {
+		if _, err := t.db.UpdateAwaySpan(ctx, store.UpdateAwaySpanParams{
+			ID:    latestAway.ID,
+			EndAt: now,
+		}); err != nil {
+			return fmt.Errorf("update away span: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := t.db.InsertAwaySpan(ctx, store.InsertAwaySpanParams{
+		Kind:    kind,
+		Reason:  reason,
+		StartAt: startAt,
+		EndAt:   now,
+	}); err != nil {
+		return fmt.Errorf("insert away span: %w", err)
+	}
+
+	slog.Debug("New away span", "kind", kind, "reason", reason)
+
+	return nil
+}
+
+// RecordStopped marks the moment the daemon stops. The record is extended to cover the downtime on the next start.
+func (t *Tracker) RecordStopped(ctx context.Context) error {
+	now := t.Now().Unix()
+	if _, err := t.db.InsertAwaySpan(ctx, store.InsertAwaySpanParams{
+		Kind:    AwayAway,
+		Reason:  ReasonDaemonStopped,
+		StartAt: now,
+		EndAt:   now,
+	}); err != nil {
+		return fmt.Errorf("insert away span: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// BrowserTab is the active tab of the focused browser window, as reported by the browser extension.
+type BrowserTab struct {
+	URL       string
+	Domain    string
+	Title     string
+	Incognito bool
+}
+
+// DomainFromURL returns the host of rawURL without a leading "www.", or "" if it has none (e.g. about:blank).
+func DomainFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// UpdateBrowserTab records the active browser tab and schedules a collection, since switching tabs usually changes
+// the window title.
+func (t *Tracker) UpdateBrowserTab(tab BrowserTab) {
+	if tab.Domain == "" {
+		tab.Domain = DomainFromURL(tab.URL)
+	}
+
+	t.mu.Lock()
+	t.browserTab = &tab
+	t.mu.Unlock()
+
+	if tab.Incognito {
+		// nothing identifying about private browsing ends up in the logs either
+		slog.Debug("Browser tab changed", "incognito", true)
+	} else {
+		slog.Debug("Browser tab changed", "domain", tab.Domain)
+	}
+	t.Wake()
+}
+
+// attachBrowserTab stores the latest browser tab with span if the span's window shows that tab. Browsers put the tab
+// title in the window title, which tells the browser window apart from any other focused app.
+func (t *Tracker) attachBrowserTab(ctx context.Context, span store.Span) error {
+	t.mu.Lock()
+	tab := t.browserTab
+	t.mu.Unlock()
+
+	if tab == nil || tab.Title == "" || !strings.Contains(span.WindowTitle, tab.Title) {
+		return nil
+	}
+
+	tabURL, domain, title := tab.URL, tab.Domain, tab.Title
+	if t.privacy.DropBrowserURLs {
+		tabURL = ""
+	}
+	if tab.Incognito {
+		// private browsing is never stored, whatever the privacy settings
+		tabURL, domain, title = "", "", ""
+	}
+
+	// transitional, the attributes below are the source of truth (see Attribute)
+	if err := t.db.UpsertSpanBrowserTab(ctx, store.UpsertSpanBrowserTabParams{
+		SpanID:    span.ID,
+		Url:       tabURL,
+		Domain:    domain,
+		Title:     title,
+		Incognito: tab.Incognito,
+	}); err != nil {
+		return fmt.Errorf("upsert span browser tab: %w", err)
+	}
+
+	attrs := []Attribute{BoolAttr(AttrIncognito, tab.Incognito)}
+	if domain != "" {
+		attrs = append(attrs, StringAttr(AttrDomain, domain))
+	}
+	if tabURL != "" {
+		attrs = append(attrs, StringAttr(AttrURL, tabURL))
+	}
+	return t.setAttributes(ctx, span.ID, attrs...)
+}
@@ -0,0 +1,105 @@
+package tracker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Status describes what the tracker is doing, for the control socket.
+type Status struct {
+	Paused      bool            `json:"paused"`
+	PausedUntil *time.Time      `json:"paused_until,omitempty"` // nil while paused until resumed
+	LastPoll    *time.Time      `json:"last_poll,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	LastErrorAt *time.Time      `json:"last_error_at,omitempty"`
+	CurrentSpan *store.Span     `json:"current_span,omitempty"`
+	CurrentAway *store.AwaySpan `json:"current_away,omitempty"`
+}
+
+// Pause stops recording spans for d, or until Resume when d is 0. The time is recorded as a paused away span.
+func (t *Tracker) Pause(d time.Duration) {
+	t.mu.Lock()
+	if !t.paused {
+		t.pausedAt = t.Now()
+	}
+	t.paused = true
+	t.pausedUntil = time.Time{}
+	if d > 0 {
+		t.pausedUntil = t.Now().Add(d)
+	}
+	t.mu.Unlock()
+
+	slog.Info("Tracking paused", "duration", d)
+	t.Wake()
+}
+
+// Resume continues recording spans after Pause.
+func (t *Tracker) Resume() {
+	t.mu.Lock()
+	t.paused = false
+	t.mu.Unlock()
+
+	slog.Info("Tracking resumed")
+	t.Wake()
+}
+
+// pausedSince reports whether tracking is paused at now and since when, ending the pause once it expired.
+func (t *Tracker) pausedSince(now time.Time) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused && !t.pausedUntil.IsZero() && !now.Before(t.pausedUntil) {
+		t.paused = false
+		slog.Info("Pause expired, tracking resumed")
+	}
+	return t.pausedAt, t.paused
+}
+
+// recordPoll keeps the outcome of the latest collection for Status.
+func (t *Tracker) recordPoll(at time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastPoll = at
+	if err != nil {
+		t.lastErr = err.Error()
+		t.lastErrAt = at
+	}
+}
+
+// Status returns the pause state, the outcome of the latest collection and the current span or away span.
+func (t *Tracker) Status(ctx context.Context) (Status, error) {
+	_, paused := t.pausedSince(t.Now())
+
+	t.mu.Lock()
+	status := Status{
+		Paused:    paused,
+		LastError: t.lastErr,
+	}
+	// copies, the fields keep changing after the lock is released
+	if pausedUntil := t.pausedUntil; paused && !pausedUntil.IsZero() {
+		status.PausedUntil = &pausedUntil
+	}
+	if lastPoll := t.lastPoll; !lastPoll.IsZero() {
+		status.LastPoll = &lastPoll
+	}
+	if lastErrAt := t.lastErrAt; !lastErrAt.IsZero() {
+		status.LastErrorAt = &lastErrAt
+	}
+	t.mu.Unlock()
+
+	latestSpan, latestAway, err := t.latestActivity(ctx)
+	if err != nil {
+		return status, err
+	}
+	if latestAway.ID > 0 && latestAway.EndAt >= latestSpan.EndAt {
+		status.CurrentAway = &latestAway
+	} else if latestSpan.ID > 0 {
+		status.CurrentSpan = &latestSpan
+	}
+
+	return status, nil
+}
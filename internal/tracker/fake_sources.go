@@ -0,0 +1,139 @@
+package tracker
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeWindowSource is an in-memory WindowSource. It also implements FocusWatcher, notifying watchers whenever the
+// active window is replaced.
+type FakeWindowSource struct {
+	mu       sync.Mutex
+	windows  []WindowInfo
+	err      error
+	watchers map[*func(WindowInfo)]struct{}
+}
+
+// SetActive replaces the window list with a single focused window.
+func (f *FakeWindowSource) SetActive(app, title string) {
+	f.SetWindows([]WindowInfo{{AppName: app, RawAppName: app, WindowTitle: title, IsActive: true}})
+}
+
+// SetWindows replaces the window list.
+func (f *FakeWindowSource) SetWindows(windows []WindowInfo) {
+	f.mu.Lock()
+	f.windows = windows
+	watchers := make([]func(WindowInfo), 0, len(f.watchers))
+	for fn := range f.watchers {
+		watchers = append(watchers, *fn)
+	}
+	f.mu.Unlock()
+
+	for _, w := range windows {
+		if !w.IsActive {
+			continue
+		}
+		for _, fn := range watchers {
+			fn(w)
+		}
+		break
+	}
+}
+
+func (f *FakeWindowSource) WatchFocus(ctx context.Context, fn func(WindowInfo)) error {
+	f.mu.Lock()
+	if f.watchers == nil {
+		f.watchers = make(map[*func(WindowInfo)]struct{})
+	}
+	f.watchers[&fn] = struct{}{}
+	f.mu.Unlock()
+
+	<-ctx.Done()
+
+	f.mu.Lock()
+	delete(f.watchers, &fn)
+	f.mu.Unlock()
+	return ctx.Err()
+}
+
+// SetError makes subsequent GetWindows calls fail with err.
+func (f *FakeWindowSource) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *FakeWindowSource) GetWindows() ([]WindowInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return append([]WindowInfo(nil), f.windows...), nil
+}
+
+// FakeIdleSource is an in-memory IdleSource.
+type FakeIdleSource struct {
+	mu      sync.Mutex
+	seconds float64
+	locked  bool
+	err     error
+}
+
+// SetIdle sets the reported idle time in seconds.
+func (f *FakeIdleSource) SetIdle(seconds float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seconds = seconds
+}
+
+// SetError makes subsequent GetIdleTime calls fail with err.
+func (f *FakeIdleSource) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// SetLocked sets whether the screen is reported as locked.
+func (f *FakeIdleSource) SetLocked(locked bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.locked = locked
+}
+
+func (f *FakeIdleSource) GetIdleTime() (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seconds, f.err
+}
+
+func (f *FakeIdleSource) IsScreenLocked() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.locked, nil
+}
+
+// FakePowerSource is an in-memory PowerSource.
+type FakePowerSource struct {
+	mu         sync.Mutex
+	assertions []PowerAssertionInfo
+}
+
+// SetAssertions replaces the active power assertions.
+func (f *FakePowerSource) SetAssertions(assertions []PowerAssertionInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.assertions = assertions
+}
+
+func (f *FakePowerSource) HasActivePowerAssertions() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.assertions) > 0, nil
+}
+
+func (f *FakePowerSource) GetPowerAssertions() ([]PowerAssertionInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]PowerAssertionInfo(nil), f.assertions...), nil
+}
@@ -0,0 +1,76 @@
+//go:build darwin
+
+package tracker
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+#include "focus_darwin.h"
+*/
+import "C"
+import (
+	"context"
+	"sync"
+)
+
+var (
+	focusListenersMu sync.Mutex
+	focusListeners   = map[chan struct{}]struct{}{}
+)
+
+//export goFocusChanged
+func goFocusChanged() {
+	focusListenersMu.Lock()
+	defer focusListenersMu.Unlock()
+	for ch := range focusListeners {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// WatchFocus calls fn whenever NSWorkspace reports that another application was activated.
+// Title changes within an app are still picked up by polling. Notifications are only delivered while RunMainLoop is
+// running on the main thread.
+func (macWindows) WatchFocus(ctx context.Context, fn func(WindowInfo)) error {
+	activated := make(chan struct{}, 1)
+
+	focusListenersMu.Lock()
+	focusListeners[activated] = struct{}{}
+	focusListenersMu.Unlock()
+	defer func() {
+		focusListenersMu.Lock()
+		delete(focusListeners, activated)
+		focusListenersMu.Unlock()
+	}()
+
+	C.startFocusObserver()
+
+	for {
+		select {
+		case <-activated:
+			windows, err := GetWindows()
+			if err != nil {
+				continue
+			}
+			for _, w := range windows {
+				if w.IsActive {
+					fn(w)
+					break
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// RunMainLoop runs the Cocoa main run loop until ctx is cancelled, so that NSWorkspace notifications are delivered.
+// It must be called from the main goroutine with the main OS thread locked (see runtime.LockOSThread).
+func RunMainLoop(ctx context.Context) {
+	stop := context.AfterFunc(ctx, func() { C.stopMainRunLoop() })
+	defer stop()
+
+	C.runMainRunLoop()
+}
@@ -0,0 +1,12 @@
+//go:build darwin
+
+#import <Cocoa/Cocoa.h>
+
+// Start observing NSWorkspaceDidActivateApplicationNotification, calling goFocusChanged on every activation
+void startFocusObserver();
+
+// Run the main run loop, which delivers NSWorkspace notifications. Must be called on the main thread.
+void runMainRunLoop();
+
+// Stop the main run loop started by runMainRunLoop
+void stopMainRunLoop();
@@ -0,0 +1,40 @@
+//go:build darwin
+
+#import "focus_darwin.h"
+#include "_cgo_export.h"
+
+static id activationObserver = nil;
+
+void startFocusObserver() {
+    // NSWorkspace posts its notifications on the main thread, so register there
+    dispatch_async(dispatch_get_main_queue(), ^{
+        if (activationObserver) return;
+
+        NSNotificationCenter *center = [[NSWorkspace sharedWorkspace] notificationCenter];
+        activationObserver = [center addObserverForName:NSWorkspaceDidActivateApplicationNotification
+                                                 object:nil
+                                                  queue:nil
+                                             usingBlock:^(NSNotification *note) {
+            goFocusChanged();
+        }];
+    });
+}
+
+void runMainRunLoop() {
+    // A run loop without sources returns immediately, so keep it alive with a timer that never fires
+    CFRunLoopTimerRef keepAlive = CFRunLoopTimerCreateWithHandler(
+        NULL, CFAbsoluteTimeGetCurrent() + 1e10, 1e10, 0, 0, ^(CFRunLoopTimerRef timer) {});
+    CFRunLoopAddTimer(CFRunLoopGetCurrent(), keepAlive, kCFRunLoopCommonModes);
+
+    CFRunLoopRun();
+
+    CFRunLoopRemoveTimer(CFRunLoopGetCurrent(), keepAlive, kCFRunLoopCommonModes);
+    CFRelease(keepAlive);
+}
+
+void stopMainRunLoop() {
+    // Dispatching to the main queue means a stop requested before the loop started still takes effect
+    dispatch_async(dispatch_get_main_queue(), ^{
+        CFRunLoopStop(CFRunLoopGetMain());
+    });
+}
@@ -0,0 +1,77 @@
+package tracker
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// gapKinds are the away kinds during which the user was away from the machine. Paused and private time was the
+// user's choice, so it isn't asked about.
+var gapKinds = []string{AwayIdle, AwayAsleep, AwayLocked, AwayAway}
+
+// recordUnaccountedGap is called when a focused span is about to be saved. If the user is returning from an away
+// period of at least minGap, it is recorded as an unaccounted gap they can then assign to a project, mark as a break
+// or meeting, or discard. Consecutive away spans (e.g. idle, then asleep) form one gap, and time already covered by a
+// manual entry (a running timer) is not asked about.
+func (t *Tracker) recordUnaccountedGap(ctx context.Context, now int64) error {
+	if t.minGap <= 0 {
+		return nil
+	}
+
+	latestSpan, latestAway, err := t.latestActivity(ctx)
+	if err != nil {
+		return err
+	}
+	if latestAway.ID == 0 || latestAway.EndAt < latestSpan.EndAt {
+		return nil // not returning from away
+	}
+
+	aways, err := t.db.SelectAwaySpans(ctx, store.SelectAwaySpansParams{
+		StartAt: latestSpan.EndAt - 1,
+		EndAt:   now + 1,
+	})
+	if err != nil {
+		return fmt.Errorf("select away spans: %w", err)
+	}
+	slices.SortFunc(aways, func(a, b store.AwaySpan) int {
+		return cmp.Compare(a.StartAt, b.StartAt)
+	})
+
+	// the away spans since the latest span, back to the first one the user chose (paused, private)
+	startAt, endAt := latestAway.EndAt, latestAway.EndAt
+	for i := len(aways) - 1; i >= 0 && slices.Contains(gapKinds, aways[i].Kind); i-- {
+		startAt = min(startAt, aways[i].StartAt)
+	}
+	if time.Duration(endAt-startAt)*time.Second < t.minGap {
+		return nil
+	}
+
+	entries, err := t.db.SelectManualEntries(ctx, store.SelectManualEntriesParams{
+		StartAt: startAt,
+		EndAt:   endAt,
+	})
+	if err != nil {
+		return fmt.Errorf("select manual entries: %w", err)
+	}
+	if len(entries) > 0 {
+		return nil
+	}
+
+	if _, err := t.db.InsertUnaccountedGap(ctx, store.InsertUnaccountedGapParams{
+		StartAt: startAt,
+		EndAt:   endAt,
+		Kind:    latestAway.Kind,
+	}); err != nil {
+		return fmt.Errorf("insert unaccounted gap: %w", err)
+	}
+
+	slog.Info("Recorded unaccounted gap", "kind", latestAway.Kind, "from", startAt, "to", endAt)
+
+	return nil
+}
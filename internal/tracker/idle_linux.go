@@ -0,0 +1,184 @@
+//go:build linux
+
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/screensaver"
+	"github.com/jezek/xgb/xproto"
+)
+
+const (
+	logindService      = "org.freedesktop.login1"
+	logindSessionPath  = dbus.ObjectPath("/org/freedesktop/login1/session/auto")
+	logindSessionIface = "org.freedesktop.login1.Session"
+)
+
+// errNoIdleHint is returned by LogindIdle when the desktop never publishes an idle hint to logind.
+var errNoIdleHint = errors.New("session has no idle hint")
+
+// LogindIdle reads the IdleHint/IdleSinceHint properties of the caller's systemd-logind session.
+// The hint is set by the desktop environment after its own idle delay, so it works on Wayland but is coarser than
+// X11ScreenSaverIdle.
+type LogindIdle struct {
+	session dbus.BusObject
+	now     func() time.Time
+}
+
+// NewLogindIdle reads the session properties over conn, usually the system bus.
+func NewLogindIdle(conn *dbus.Conn) *LogindIdle {
+	return &LogindIdle{
+		session: conn.Object(logindService, logindSessionPath),
+		now:     time.Now,
+	}
+}
+
+func (l *LogindIdle) GetIdleTime() (float64, error) {
+	var idleHint bool
+	if err := l.getProperty("IdleHint", &idleHint); err != nil {
+		return 0, err
+	}
+
+	// IdleSinceHint is a CLOCK_REALTIME timestamp in microseconds of the last IdleHint change
+	var idleSince uint64
+	if err := l.getProperty("IdleSinceHint", &idleSince); err != nil {
+		return 0, err
+	}
+	if idleSince == 0 {
+		return 0, errNoIdleHint
+	}
+
+	if !idleHint {
+		return 0, nil
+	}
+
+	idleFor := l.now().Sub(time.UnixMicro(int64(idleSince)))
+	return max(idleFor.Seconds(), 0), nil
+}
+
+// IsScreenLocked reads the session's LockedHint, which screen lockers set while the session is locked.
+func (l *LogindIdle) IsScreenLocked() (bool, error) {
+	var locked bool
+	if err := l.getProperty("LockedHint", &locked); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func (l *LogindIdle) getProperty(name string, dst any) error {
+	v, err := l.session.GetProperty(logindSessionIface + "." + name)
+	if err != nil {
+		return fmt.Errorf("get logind %s: %w", name, err)
+	}
+	if err := v.Store(dst); err != nil {
+		return fmt.Errorf("decode logind %s: %w", name, err)
+	}
+	return nil
+}
+
+// X11ScreenSaverIdle reads the time since the last input from the MIT-SCREEN-SAVER X extension.
+type X11ScreenSaverIdle struct {
+	display string
+
+	mu   sync.Mutex
+	conn *xgb.Conn
+	root xproto.Window
+}
+
+// NewX11ScreenSaverIdle connects to the X server at display (e.g. ":0"), or $DISPLAY when empty.
+func NewX11ScreenSaverIdle(display string) (*X11ScreenSaverIdle, error) {
+	x := &X11ScreenSaverIdle{display: display}
+	if err := x.connect(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *X11ScreenSaverIdle) connect() error {
+	conn, err := xgb.NewConnDisplay(x.display)
+	if err != nil {
+		return fmt.Errorf("connect to X server: %w", err)
+	}
+	if err := screensaver.Init(conn); err != nil {
+		conn.Close()
+		return fmt.Errorf("init screensaver extension: %w", err)
+	}
+
+	x.conn = conn
+	x.root = xproto.Setup(conn).DefaultScreen(conn).Root
+	return nil
+}
+
+// Close closes the X server connection.
+func (x *X11ScreenSaverIdle) Close() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conn != nil {
+		x.conn.Close()
+		x.conn = nil
+	}
+}
+
+func (x *X11ScreenSaverIdle) GetIdleTime() (float64, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.conn == nil {
+		if err := x.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	info, err := screensaver.QueryInfo(x.conn, xproto.Drawable(x.root)).Reply()
+	if err != nil {
+		x.conn.Close()
+		x.conn = nil
+		return 0, fmt.Errorf("query screensaver info: %w", err)
+	}
+
+	return float64(info.MsSinceUserInput) / 1e3, nil
+}
+
+// fallbackIdle returns the idle time of the first source that succeeds.
+type fallbackIdle []IdleSource
+
+// newFallbackIdle prefers the X screensaver extension, which reports the time since the last input, over logind's idle
+// hint, which stays unset until the desktop's own idle delay (often minutes) has passed. logind is the fallback for
+// Wayland sessions and X servers without the extension. Nil sources are left out.
+func newFallbackIdle(screenSaver, logind IdleSource) fallbackIdle {
+	var idle fallbackIdle
+	for _, src := range []IdleSource{screenSaver, logind} {
+		if src != nil {
+			idle = append(idle, src)
+		}
+	}
+	return idle
+}
+
+func (f fallbackIdle) GetIdleTime() (float64, error) {
+	var errs []error
+	for _, src := range f {
+		seconds, err := src.GetIdleTime()
+		if err == nil {
+			return seconds, nil
+		}
+		errs = append(errs, err)
+	}
+	return 0, errors.Join(errs...)
+}
+
+// IsScreenLocked asks the first source that supports lock detection.
+func (f fallbackIdle) IsScreenLocked() (bool, error) {
+	for _, src := range f {
+		if lockSource, ok := src.(LockSource); ok {
+			return lockSource.IsScreenLocked()
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,142 @@
+package tracker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+func TestNewFallbackIdle(t *testing.T) {
+	fake := func(seconds float64, err error) *FakeIdleSource {
+		f := &FakeIdleSource{}
+		f.SetIdle(seconds)
+		f.SetError(err)
+		return f
+	}
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name        string
+		screenSaver IdleSource
+		logind      IdleSource
+		want        float64
+		wantErr     bool
+	}{
+		{"x11 prefers screensaver", fake(42, nil), fake(0, nil), 42, false},
+		{"wayland uses logind", nil, fake(300, nil), 300, false},
+		{"screensaver failing falls back to logind", fake(0, errFailed), fake(300, nil), 300, false},
+		{"logind unavailable", fake(42, nil), nil, 42, false},
+		{"all failing", fake(0, errFailed), fake(0, errNoIdleHint), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newFallbackIdle(tt.screenSaver, tt.logind).GetIdleTime()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetIdleTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetIdleTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFallbackIdleSkipsNil(t *testing.T) {
+	if idle := newFallbackIdle(nil, nil); len(idle) != 0 {
+		t.Errorf("newFallbackIdle(nil, nil) has %d sources, want 0", len(idle))
+	}
+}
+
+func TestLogindIdle(t *testing.T) {
+	address := privateBus(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name       string
+		props      map[string]any
+		want       float64
+		wantErr    bool
+		wantLocked bool
+	}{
+		{
+			name:  "active session",
+			props: map[string]any{"IdleHint": false, "IdleSinceHint": uint64(now.Add(-time.Hour).UnixMicro()), "LockedHint": false},
+			want:  0,
+		},
+		{
+			name:  "idle since the hint",
+			props: map[string]any{"IdleHint": true, "IdleSinceHint": uint64(now.Add(-90 * time.Second).UnixMicro()), "LockedHint": false},
+			want:  90,
+		},
+		{
+			name:       "locked",
+			props:      map[string]any{"IdleHint": true, "IdleSinceHint": uint64(now.Add(-10 * time.Minute).UnixMicro()), "LockedHint": true},
+			want:       600,
+			wantLocked: true,
+		},
+		{
+			name:  "hint from the future",
+			props: map[string]any{"IdleHint": true, "IdleSinceHint": uint64(now.Add(time.Second).UnixMicro()), "LockedHint": false},
+			want:  0,
+		},
+		{
+			name:    "desktop never sets the hint",
+			props:   map[string]any{"IdleHint": false, "IdleSinceHint": uint64(0), "LockedHint": false},
+			wantErr: true,
+		},
+		{
+			name:    "property of the wrong type",
+			props:   map[string]any{"IdleHint": "yes", "IdleSinceHint": uint64(1), "LockedHint": false},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a stand-in for the login1 session object of the caller
+			logind := connectBus(t, address)
+			session := make(map[string]*prop.Prop, len(tt.props))
+			for name, value := range tt.props {
+				session[name] = &prop.Prop{Value: value}
+			}
+			if _, err := prop.Export(logind, logindSessionPath, prop.Map{logindSessionIface: session}); err != nil {
+				t.Fatalf("export session properties: %v", err)
+			}
+			if reply, err := logind.RequestName(logindService, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+				t.Fatalf("RequestName() = %v, %v", reply, err)
+			}
+			defer logind.ReleaseName(logindService)
+
+			idle := NewLogindIdle(connectBus(t, address))
+			idle.now = func() time.Time { return now }
+
+			got, err := idle.GetIdleTime()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetIdleTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetIdleTime() = %v, want %v", got, tt.want)
+			}
+
+			locked, err := idle.IsScreenLocked()
+			if err != nil {
+				t.Fatalf("IsScreenLocked() error = %v", err)
+			}
+			if locked != tt.wantLocked {
+				t.Errorf("IsScreenLocked() = %v, want %v", locked, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestLogindIdleNoSession(t *testing.T) {
+	idle := NewLogindIdle(connectBus(t, privateBus(t)))
+	if _, err := idle.GetIdleTime(); err == nil {
+		t.Errorf("GetIdleTime() without logind error = nil, want an error")
+	}
+	if _, err := idle.IsScreenLocked(); err == nil {
+		t.Errorf("IsScreenLocked() without logind error = nil, want an error")
+	}
+}
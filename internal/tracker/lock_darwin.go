@@ -0,0 +1,15 @@
+//go:build darwin
+
+package tracker
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework CoreGraphics -framework CoreFoundation
+#include "lock_darwin.h"
+*/
+import "C"
+
+// IsScreenLocked checks the CGSSessionScreenIsLocked flag of the current login session.
+func IsScreenLocked() (bool, error) {
+	return C.isScreenLocked() == 1, nil
+}
@@ -0,0 +1,6 @@
+//go:build darwin
+
+#import <CoreGraphics/CoreGraphics.h>
+
+// Returns 1 if the login session's screen is locked, 0 otherwise
+int isScreenLocked();
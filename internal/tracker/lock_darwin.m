@@ -0,0 +1,19 @@
+//go:build darwin
+
+#import "lock_darwin.h"
+
+int isScreenLocked() {
+    CFDictionaryRef session = CGSessionCopyCurrentDictionary();
+    if (!session) {
+        return 0;
+    }
+
+    int locked = 0;
+    CFBooleanRef lockedRef = CFDictionaryGetValue(session, CFSTR("CGSSessionScreenIsLocked"));
+    if (lockedRef && CFBooleanGetValue(lockedRef)) {
+        locked = 1;
+    }
+
+    CFRelease(session);
+    return locked;
+}
@@ -0,0 +1,12 @@
+//go:build !darwin
+
+package tracker
+
+import (
+	"context"
+)
+
+// RunMainLoop blocks until ctx is cancelled. Only macOS needs a main run loop to deliver focus notifications.
+func RunMainLoop(ctx context.Context) {
+	<-ctx.Done()
+}
@@ -0,0 +1,67 @@
+package tracker
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TitleRewrite replaces matches of Pattern in the window titles of apps matching App.
+type TitleRewrite struct {
+	App     *regexp.Regexp // matched against the app name and app id, nil matches any app
+	Pattern *regexp.Regexp
+	Replace string // may refer to capture groups, e.g. "$1"
+}
+
+func (r TitleRewrite) appliesTo(active WindowInfo) bool {
+	return r.App == nil || r.App.MatchString(active.AppName) || active.AppID != "" && r.App.MatchString(active.AppID)
+}
+
+// builtinTitleRewrites strip the parts of window titles that change without the user switching context, which would
+// otherwise start a new span every time.
+var builtinTitleRewrites = []TitleRewrite{
+	// unread counters, e.g. "(3) general - Acme - Slack" or "[2] Inbox"
+	{Pattern: regexp.MustCompile(`^[(\[]\d+\+?[)\]]\s+`)},
+	// unread counters after the mailbox name, e.g. "Inbox (1,234) - me@example.com - Gmail"
+	{Pattern: regexp.MustCompile(`^(Inbox|All Mail|Posteingang) \(\d[\d,.]*\)`), Replace: "$1"},
+	// spinners of terminal programs, e.g. "⠹ npm install"
+	{Pattern: regexp.MustCompile(`[\x{2800}-\x{28FF}◐◓◑◒◴◷◶◵]\s*`)},
+	// unsaved markers of editors, e.g. "● main.go - mac-time-tracker - Visual Studio Code"
+	{
+		App:     regexp.MustCompile(`(?i)code|cursor|windsurf|zed|sublime|goland|intellij|pycharm|webstorm|gedit|kate`),
+		Pattern: regexp.MustCompile(`^(?:[●•]\s*|\*)|\s+[●•]$`),
+	},
+	// the modified marker vim and neovim put after the file name, e.g. "main.go + (~/src) - NVIM"
+	{
+		App:     regexp.MustCompile(`(?i)vim|terminal|kitty|alacritty|wezterm|ghostty|iterm|konsole`),
+		Pattern: regexp.MustCompile(` \+ \(`),
+		Replace: " (",
+	},
+}
+
+// normalizeTitle applies the built-in and configured title rewrites to the focused window, so that spans are compared
+// and stored with the normalized title. A title rewritten to nothing is kept as it was.
+func (t *Tracker) normalizeTitle(active WindowInfo) WindowInfo {
+	title := active.WindowTitle
+
+	rewrites := t.titles.Rewrites
+	if t.titles.Normalize {
+		rewrites = slices.Concat(builtinTitleRewrites, rewrites)
+	}
+	for _, rewrite := range rewrites {
+		if rewrite.appliesTo(active) {
+			title = rewrite.Pattern.ReplaceAllString(title, rewrite.Replace)
+		}
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" || title == active.WindowTitle {
+		return active
+	}
+
+	if t.titles.KeepRaw {
+		active.RawWindowTitle = active.WindowTitle
+	}
+	active.WindowTitle = title
+	return active
+}
@@ -0,0 +1,94 @@
+package tracker
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	normalize := Titles{Normalize: true}
+
+	tests := []struct {
+		name    string
+		titles  Titles
+		app     string
+		appID   string
+		title   string
+		want    string
+		wantRaw string
+	}{
+		{name: "unread counter", titles: normalize, app: "Slack", title: "(3) general - Acme - Slack", want: "general - Acme - Slack"},
+		{name: "unread counter in brackets", titles: normalize, app: "Thunderbird", title: "[2] Inbox", want: "Inbox"},
+		{name: "capped unread counter", titles: normalize, app: "Firefox", title: "(99+) Feed | LinkedIn — Mozilla Firefox", want: "Feed | LinkedIn — Mozilla Firefox"},
+		{name: "counter after the mailbox", titles: normalize, app: "Google Chrome", title: "Inbox (1,234) - me@example.com - Gmail", want: "Inbox - me@example.com - Gmail"},
+		{name: "counter after a localized mailbox", titles: normalize, app: "Google Chrome", title: "Posteingang (12) - me@example.de - Gmail", want: "Posteingang - me@example.de - Gmail"},
+		{name: "spinner", titles: normalize, app: "Terminal", title: "⠹ npm install", want: "npm install"},
+		{name: "spinner of another style", titles: normalize, app: "kitty", title: "◐ cargo build", want: "cargo build"},
+		{name: "unsaved marker before the file", titles: normalize, app: "Code", title: "● main.go - mac-time-tracker - Visual Studio Code", want: "main.go - mac-time-tracker - Visual Studio Code"},
+		{name: "unsaved marker after the file", titles: normalize, app: "Zed", title: "main.go •", want: "main.go"},
+		{name: "unsaved asterisk", titles: normalize, app: "gedit", title: "*notes.txt (~/Documents) - gedit", want: "notes.txt (~/Documents) - gedit"},
+		{name: "unsaved marker of an editor app id", titles: normalize, app: "Editor", appID: "/usr/share/code/code", title: "● main.go - Visual Studio Code", want: "main.go - Visual Studio Code"},
+		{name: "bullet in other apps", titles: normalize, app: "Spotify", title: "● Live", want: "● Live"},
+		{name: "vim modified marker", titles: normalize, app: "kitty", title: "main.go + (~/src) - NVIM", want: "main.go (~/src) - NVIM"},
+		{name: "plus in other apps", titles: normalize, app: "Firefox", title: "C + (Programming) — Mozilla Firefox", want: "C + (Programming) — Mozilla Firefox"},
+		{name: "nothing to normalize", titles: normalize, app: "Slack", title: "general - Acme - Slack", want: "general - Acme - Slack"},
+		{name: "normalize off", app: "Slack", title: "(3) general - Acme - Slack", want: "(3) general - Acme - Slack"},
+		{
+			name:   "user rewrite",
+			titles: Titles{Rewrites: []TitleRewrite{{Pattern: regexp.MustCompile(` - Acme - Slack$`)}}},
+			app:    "Slack",
+			title:  "general - Acme - Slack",
+			want:   "general",
+		},
+		{
+			name:   "user rewrite with a capture group",
+			titles: Titles{Rewrites: []TitleRewrite{{Pattern: regexp.MustCompile(`^Jira - (\w+-\d+).*`), Replace: "$1"}}},
+			app:    "Firefox",
+			title:  "Jira - MTT-42 Fix the tracker — Mozilla Firefox",
+			want:   "MTT-42",
+		},
+		{
+			name:   "user rewrite of another app",
+			titles: Titles{Rewrites: []TitleRewrite{{App: regexp.MustCompile(`^Slack$`), Pattern: regexp.MustCompile(` - .*`)}}},
+			app:    "Firefox",
+			title:  "Example - Mozilla Firefox",
+			want:   "Example - Mozilla Firefox",
+		},
+		{
+			name: "user rewrites apply after the built-in ones",
+			titles: Titles{Normalize: true, Rewrites: []TitleRewrite{
+				{App: regexp.MustCompile(`Slack`), Pattern: regexp.MustCompile(`^general`), Replace: "#general"},
+			}},
+			app:   "Slack",
+			title: "(3) general - Acme - Slack",
+			want:  "#general - Acme - Slack",
+		},
+		{
+			name:   "title rewritten to nothing is kept",
+			titles: Titles{Rewrites: []TitleRewrite{{Pattern: regexp.MustCompile(`.*`)}}},
+			app:    "Slack",
+			title:  "general - Acme - Slack",
+			want:   "general - Acme - Slack",
+		},
+		{
+			name:    "raw title kept",
+			titles:  Titles{Normalize: true, KeepRaw: true},
+			app:     "Slack",
+			title:   "(3) general - Acme - Slack",
+			want:    "general - Acme - Slack",
+			wantRaw: "(3) general - Acme - Slack",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &Tracker{titles: tt.titles}
+			got := tracker.normalizeTitle(WindowInfo{AppName: tt.app, AppID: tt.appID, WindowTitle: tt.title})
+			if got.WindowTitle != tt.want {
+				t.Errorf("normalizeTitle() title = %q, want %q", got.WindowTitle, tt.want)
+			}
+			if got.RawWindowTitle != tt.wantRaw {
+				t.Errorf("normalizeTitle() raw title = %q, want %q", got.RawWindowTitle, tt.wantRaw)
+			}
+		})
+	}
+}
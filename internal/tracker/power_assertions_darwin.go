@@ -12,13 +12,6 @@ import (
 	"unsafe"
 )
 
-// PowerAssertionInfo represents a single power assertion
-type PowerAssertionInfo struct {
-	ProcessName   string
-	PID           int32
-	AssertionType string
-}
-
 // HasActivePowerAssertions checks if any NoDisplaySleep assertions exist.
 // Returns true if apps are preventing display sleep (e.g., Zoom, video playback).
 func HasActivePowerAssertions() (bool, error) {
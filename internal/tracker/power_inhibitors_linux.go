@@ -0,0 +1,86 @@
+//go:build linux
+
+package tracker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	logindManagerPath  = dbus.ObjectPath("/org/freedesktop/login1")
+	logindManagerIface = "org.freedesktop.login1.Manager"
+)
+
+// LogindInhibitors reports systemd-logind inhibitor locks, the Linux equivalent of IOKit power assertions.
+// Video call apps, browsers playing video and media players take "idle" locks. "sleep" locks only keep the machine from
+// suspending (downloads, backups, package managers) and don't mean someone is watching the screen.
+type LogindInhibitors struct {
+	manager dbus.BusObject
+}
+
+// NewLogindInhibitors lists inhibitors over conn, usually the system bus.
+func NewLogindInhibitors(conn *dbus.Conn) *LogindInhibitors {
+	return &LogindInhibitors{
+		manager: conn.Object(logindService, logindManagerPath),
+	}
+}
+
+// logindInhibitor matches the (ssssuu) struct returned by ListInhibitors
+type logindInhibitor struct {
+	What string // colon separated, e.g. "sleep:idle"
+	Who  string
+	Why  string
+	Mode string // "block" or "delay"
+	UID  uint32
+	PID  uint32
+}
+
+// HasActivePowerAssertions returns true if any process blocks idle.
+// Delay locks are ignored, logind holds them for every session to run suspend hooks.
+func (l *LogindInhibitors) HasActivePowerAssertions() (bool, error) {
+	inhibitors, err := l.list()
+	if err != nil {
+		return false, err
+	}
+
+	for _, inhibitor := range inhibitors {
+		if inhibitor.Mode != "block" {
+			continue
+		}
+		for _, what := range strings.Split(inhibitor.What, ":") {
+			if what == "idle" {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// GetPowerAssertions returns all inhibitor locks for debugging/logging.
+func (l *LogindInhibitors) GetPowerAssertions() ([]PowerAssertionInfo, error) {
+	inhibitors, err := l.list()
+	if err != nil {
+		return nil, err
+	}
+
+	assertions := make([]PowerAssertionInfo, 0, len(inhibitors))
+	for _, inhibitor := range inhibitors {
+		assertions = append(assertions, PowerAssertionInfo{
+			ProcessName:   inhibitor.Who,
+			PID:           int32(inhibitor.PID),
+			AssertionType: inhibitor.Mode + ":" + inhibitor.What,
+		})
+	}
+	return assertions, nil
+}
+
+func (l *LogindInhibitors) list() ([]logindInhibitor, error) {
+	var inhibitors []logindInhibitor
+	if err := l.manager.Call(logindManagerIface+".ListInhibitors", 0).Store(&inhibitors); err != nil {
+		return nil, fmt.Errorf("list logind inhibitors: %w", err)
+	}
+	return inhibitors, nil
+}
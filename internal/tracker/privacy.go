@@ -0,0 +1,93 @@
+package tracker
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Privacy rule actions
+const (
+	PrivacyIgnore  = "ignore"  // don't record the window, the time is stored as a private away span
+	PrivacyRedact  = "redact"  // record the app with RedactedTitle as the window title
+	PrivacyCapture = "capture" // keep only the first capture group of the Title pattern as the window title
+)
+
+// RedactedTitle replaces the window title of windows matched by a redact rule.
+const RedactedTitle = "[redacted]"
+
+// PrivacyRule applies Action to windows whose app and title match. A nil pattern matches anything.
+type PrivacyRule struct {
+	App    *regexp.Regexp // matched against the app name and app id
+	Title  *regexp.Regexp
+	Action string
+}
+
+// privateWindowMarkers are added to the window title by browsers in private mode, e.g.
+// "Example Domain — Mozilla Firefox Private Browsing" or "Example Domain - Google Chrome (Incognito)".
+var privateWindowMarkers = []string{
+	"Private Browsing",
+	"(Incognito)",
+	"[InPrivate]",
+	"(Private)",
+}
+
+// filterWindow applies the privacy settings to the focused window before it is compared with and stored as a span.
+// It returns the reason the window must not be recorded, or the window with its title rewritten by the first matching
+// rule. Private browsing windows are skipped with DetectPrivateWindows, and recorded with RedactedTitle without it.
+func (t *Tracker) filterWindow(active WindowInfo) (WindowInfo, string) {
+	if t.isPrivateWindow(active) {
+		if t.privacy.DetectPrivateWindows {
+			return WindowInfo{}, ReasonPrivateWindow
+		}
+		active.WindowTitle = RedactedTitle
+		return active, ""
+	}
+
+	for _, rule := range t.privacy.Rules {
+		if rule.App != nil && !rule.App.MatchString(active.AppName) &&
+			(active.AppID == "" || !rule.App.MatchString(active.AppID)) {
+			continue
+		}
+
+		switch rule.Action {
+		case PrivacyIgnore:
+			if rule.Title == nil || rule.Title.MatchString(active.WindowTitle) {
+				return WindowInfo{}, ReasonIgnoredApp
+			}
+		case PrivacyRedact:
+			if rule.Title == nil || rule.Title.MatchString(active.WindowTitle) {
+				active.WindowTitle = RedactedTitle
+				return active, ""
+			}
+		case PrivacyCapture:
+			if rule.Title == nil {
+				continue
+			}
+			if m := rule.Title.FindStringSubmatch(active.WindowTitle); len(m) > 1 {
+				active.WindowTitle = m[1]
+				if active.WindowTitle == "" {
+					active.WindowTitle = RedactedTitle
+				}
+				return active, ""
+			}
+		}
+	}
+
+	return active, ""
+}
+
+// isPrivateWindow reports whether active is a private browsing window, either from the marker browsers add to the
+// title or because the browser extension reported the tab shown in it as incognito.
+func (t *Tracker) isPrivateWindow(active WindowInfo) bool {
+	for _, marker := range privateWindowMarkers {
+		if strings.Contains(active.WindowTitle, marker) {
+			return true
+		}
+	}
+
+	t.mu.Lock()
+	tab := t.browserTab
+	t.mu.Unlock()
+
+	return tab != nil && tab.Incognito && tab.Title != "" && strings.Contains(active.WindowTitle, tab.Title)
+}
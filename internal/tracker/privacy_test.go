@@ -0,0 +1,248 @@
+package tracker
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+func TestFilterWindow(t *testing.T) {
+	slack := WindowInfo{AppName: "Slack", AppID: "/usr/lib/slack/slack", WindowTitle: "general (Channel) - Acme - Slack"}
+	firefox := WindowInfo{AppName: "Firefox", AppID: "/usr/lib/firefox/firefox", WindowTitle: "Example Domain — Mozilla Firefox"}
+	privateFirefox := WindowInfo{AppName: "Firefox", WindowTitle: "Example Domain — Mozilla Firefox Private Browsing"}
+	incognitoTab := &BrowserTab{URL: "https://example.com", Title: "Example Domain", Incognito: true}
+	withTitle := func(w WindowInfo, title string) WindowInfo {
+		w.WindowTitle = title
+		return w
+	}
+
+	tests := []struct {
+		name       string
+		privacy    Privacy
+		browserTab *BrowserTab
+		window     WindowInfo
+		want       WindowInfo
+		wantReason string
+	}{
+		{
+			name:   "no rules",
+			window: slack,
+			want:   slack,
+		},
+		{
+			name:       "private window by its title",
+			privacy:    Privacy{DetectPrivateWindows: true},
+			window:     privateFirefox,
+			wantReason: ReasonPrivateWindow,
+		},
+		{
+			name:       "incognito tab reported by the extension",
+			privacy:    Privacy{DetectPrivateWindows: true},
+			browserTab: incognitoTab,
+			window:     firefox,
+			wantReason: ReasonPrivateWindow,
+		},
+		{
+			name:   "private window without detection is redacted",
+			window: privateFirefox,
+			want:   withTitle(privateFirefox, RedactedTitle),
+		},
+		{
+			name:       "incognito tab without detection is redacted",
+			browserTab: incognitoTab,
+			window:     firefox,
+			want:       withTitle(firefox, RedactedTitle),
+		},
+		{
+			name:       "incognito tab in another window",
+			privacy:    Privacy{DetectPrivateWindows: true},
+			browserTab: &BrowserTab{Title: "Other Page", Incognito: true},
+			window:     firefox,
+			want:       firefox,
+		},
+		{
+			name:       "regular tab",
+			privacy:    Privacy{DetectPrivateWindows: true},
+			browserTab: &BrowserTab{Title: "Example Domain"},
+			window:     firefox,
+			want:       firefox,
+		},
+		{
+			name:       "ignored app",
+			privacy:    Privacy{Rules: []PrivacyRule{{App: regexp.MustCompile(`^Slack$`), Action: PrivacyIgnore}}},
+			window:     slack,
+			wantReason: ReasonIgnoredApp,
+		},
+		{
+			name:       "ignored app id",
+			privacy:    Privacy{Rules: []PrivacyRule{{App: regexp.MustCompile(`/slack$`), Action: PrivacyIgnore}}},
+			window:     withTitle(slack, "random"),
+			wantReason: ReasonIgnoredApp,
+		},
+		{
+			name:    "other apps aren't ignored",
+			privacy: Privacy{Rules: []PrivacyRule{{App: regexp.MustCompile(`^Slack$`), Action: PrivacyIgnore}}},
+			window:  firefox,
+			want:    firefox,
+		},
+		{
+			name:       "ignored title",
+			privacy:    Privacy{Rules: []PrivacyRule{{Title: regexp.MustCompile(`(?i)bank`), Action: PrivacyIgnore}}},
+			window:     withTitle(firefox, "My Bank — Mozilla Firefox"),
+			wantReason: ReasonIgnoredApp,
+		},
+		{
+			name:    "title that doesn't match the rule",
+			privacy: Privacy{Rules: []PrivacyRule{{App: regexp.MustCompile(`Firefox`), Title: regexp.MustCompile(`(?i)bank`), Action: PrivacyIgnore}}},
+			window:  firefox,
+			want:    firefox,
+		},
+		{
+			name:    "redacted title",
+			privacy: Privacy{Rules: []PrivacyRule{{App: regexp.MustCompile(`Slack`), Action: PrivacyRedact}}},
+			window:  slack,
+			want:    withTitle(slack, RedactedTitle),
+		},
+		{
+			name:    "captured title",
+			privacy: Privacy{Rules: []PrivacyRule{{App: regexp.MustCompile(`Slack`), Title: regexp.MustCompile(`^(\S+) \(Channel\)`), Action: PrivacyCapture}}},
+			window:  slack,
+			want:    withTitle(slack, "general"),
+		},
+		{
+			name:    "empty capture is redacted",
+			privacy: Privacy{Rules: []PrivacyRule{{Title: regexp.MustCompile(`^(\d*)general`), Action: PrivacyCapture}}},
+			window:  slack,
+			want:    withTitle(slack, RedactedTitle),
+		},
+		{
+			name: "capture that doesn't match falls through to the next rule",
+			privacy: Privacy{Rules: []PrivacyRule{
+				{App: regexp.MustCompile(`Slack`), Title: regexp.MustCompile(`^(\S+) \(DM\)`), Action: PrivacyCapture},
+				{App: regexp.MustCompile(`Slack`), Action: PrivacyRedact},
+			}},
+			window: slack,
+			want:   withTitle(slack, RedactedTitle),
+		},
+		{
+			name: "first matching rule wins",
+			privacy: Privacy{Rules: []PrivacyRule{
+				{App: regexp.MustCompile(`Slack`), Action: PrivacyRedact},
+				{App: regexp.MustCompile(`Slack`), Action: PrivacyIgnore},
+			}},
+			window: slack,
+			want:   withTitle(slack, RedactedTitle),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &Tracker{privacy: tt.privacy, browserTab: tt.browserTab}
+			got, reason := tracker.filterWindow(tt.window)
+			if reason != tt.wantReason {
+				t.Errorf("filterWindow() reason = %q, want %q", reason, tt.wantReason)
+			}
+			if got != tt.want {
+				t.Errorf("filterWindow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilteredWindowIsAway(t *testing.T) {
+	tests := []struct {
+		name       string
+		privacy    Privacy
+		app, title string
+		wantReason string
+	}{
+		{
+			name:       "private window",
+			privacy:    Privacy{DetectPrivateWindows: true},
+			app:        "Firefox",
+			title:      "Secret Plans — Mozilla Firefox Private Browsing",
+			wantReason: ReasonPrivateWindow,
+		},
+		{
+			name:       "ignored app",
+			privacy:    Privacy{Rules: []PrivacyRule{{App: regexp.MustCompile(`^1Password$`), Action: PrivacyIgnore}}},
+			app:        "1Password",
+			title:      "Bank login - 1Password",
+			wantReason: ReasonIgnoredApp,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, closeDB, err := store.InitDB(filepath.Join(t.TempDir(), "db.sqlite"))
+			if err != nil {
+				t.Fatalf("InitDB() error = %v", err)
+			}
+			defer closeDB()
+
+			windows := &FakeWindowSource{}
+			tracker := New(db, Sources{Windows: windows, Idle: &FakeIdleSource{}, Power: &FakePowerSource{}}, time.Minute, 5*time.Minute)
+			tracker.applySettings(Settings{IdleThreshold: time.Minute, StaleThreshold: 5 * time.Minute, Privacy: tt.privacy})
+			now := time.Unix(1_700_000_000, 0)
+			tracker.Now = func() time.Time { return now }
+
+			windows.SetActive(tt.app, tt.title)
+			if err := tracker.CollectAndLog(ctx); err != nil {
+				t.Fatalf("CollectAndLog() error = %v", err)
+			}
+
+			// nothing about the window is stored, only the time
+			spans, err := db.SelectSpansBetween(ctx, store.SelectSpansBetweenParams{StartAt: 0, EndAt: 1 << 40})
+			if err != nil {
+				t.Fatalf("SelectSpansBetween() error = %v", err)
+			}
+			if len(spans) != 0 {
+				t.Errorf("spans = %+v, want none", spans)
+			}
+			aways, err := db.SelectAwaySpansBetween(ctx, store.SelectAwaySpansBetweenParams{StartAt: 0, EndAt: 1 << 40})
+			if err != nil {
+				t.Fatalf("SelectAwaySpansBetween() error = %v", err)
+			}
+			if len(aways) != 1 || aways[0].Kind != AwayPrivate || aways[0].Reason != tt.wantReason {
+				t.Errorf("away spans = %+v, want one %s away span for %s", aways, AwayPrivate, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestIncognitoTabNotStored(t *testing.T) {
+	ctx := context.Background()
+	db, closeDB, err := store.InitDB(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatalf("InitDB() error = %v", err)
+	}
+	defer closeDB()
+
+	span, err := db.InsertSpan(ctx, store.InsertSpanParams{AppName: "Firefox", WindowTitle: "Secret Plans — Mozilla Firefox", StartAt: 100, EndAt: 200})
+	if err != nil {
+		t.Fatalf("InsertSpan() error = %v", err)
+	}
+	tracker := New(db, Sources{}, time.Minute, 5*time.Minute)
+	tracker.UpdateBrowserTab(BrowserTab{URL: "https://example.com/plans", Title: "Secret Plans", Incognito: true})
+	if err := tracker.attachBrowserTab(ctx, span); err != nil {
+		t.Fatalf("attachBrowserTab() error = %v", err)
+	}
+
+	tab, err := db.SelectSpanBrowserTab(ctx, span.ID)
+	if err != nil {
+		t.Fatalf("SelectSpanBrowserTab() error = %v", err)
+	}
+	if tab.Url != "" || tab.Domain != "" || tab.Title != "" || !tab.Incognito {
+		t.Errorf("stored tab = %+v, want only incognito", tab)
+	}
+	attrs, err := db.SelectSpanAttributesBySpan(ctx, span.ID)
+	if err != nil {
+		t.Fatalf("SelectSpanAttributesBySpan() error = %v", err)
+	}
+	if len(attrs) != 1 || attrs[0].Key != AttrIncognito {
+		t.Errorf("attributes = %+v, want only %s", attrs, AttrIncognito)
+	}
+}
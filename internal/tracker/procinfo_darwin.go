@@ -0,0 +1,27 @@
+//go:build darwin
+
+package tracker
+
+/*
+#include <libproc.h>
+#include <sys/proc_info.h>
+
+static int parentPID(int pid) {
+    struct proc_bsdinfo info;
+    if (proc_pidinfo(pid, PROC_PIDTBSDINFO, 0, &info, sizeof(info)) != sizeof(info)) {
+        return -1;
+    }
+    return (int)info.pbi_ppid;
+}
+*/
+import "C"
+import "fmt"
+
+// parentPID returns the parent process id of pid.
+func parentPID(pid int32) (int32, error) {
+	ppid := C.parentPID(C.int(pid))
+	if ppid < 0 {
+		return 0, fmt.Errorf("no process %d", pid)
+	}
+	return int32(ppid), nil
+}
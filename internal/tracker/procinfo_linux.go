@@ -0,0 +1,46 @@
+//go:build linux
+
+package tracker
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// appIDForPID returns the executable path of pid, or fallback (the WM_CLASS or Wayland app id) when the process
+// can't be inspected, e.g. because it runs in a sandbox or belongs to another user.
+func appIDForPID(pid int32, fallback string) string {
+	if pid <= 0 {
+		return fallback
+	}
+	exe, err := os.Readlink("/proc/" + strconv.Itoa(int(pid)) + "/exe")
+	if err != nil {
+		return fallback
+	}
+	// the kernel marks executables replaced by an upgrade while the process is running
+	return strings.TrimSuffix(exe, " (deleted)")
+}
+
+// parentPID returns the parent process id of pid.
+func parentPID(pid int32) (int32, error) {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(int(pid)) + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	// "pid (comm) state ppid ...", where comm may itself contain spaces and parentheses
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return 0, fmt.Errorf("parse /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("parse /proc/%d/stat", pid)
+	}
+	ppid, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("parse /proc/%d/stat: %w", pid, err)
+	}
+	return int32(ppid), nil
+}
@@ -0,0 +1,5 @@
+//go:build !darwin && !linux
+
+package tracker
+
+func parentPID(pid int32) (int32, error) { return 0, ErrUnsupported }
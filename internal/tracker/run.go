@@ -0,0 +1,98 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// focusDebounce collapses bursts of focus events (e.g. alt-tabbing through windows) into a single collection
+	focusDebounce = 500 * time.Millisecond
+	// focusRetryInterval is how long to wait before re-subscribing after a focus watcher fails
+	focusRetryInterval = 5 * time.Second
+)
+
+// Run collects every pollInterval until ctx is cancelled, then records that the daemon stopped.
+// If the window source implements FocusWatcher, focus changes additionally trigger a collection shortly after they
+// happen (as do calls to Wake), so span boundaries don't depend on the poll interval. Settings passed to Reconfigure
+// replace pollInterval and the thresholds between collections.
+func (t *Tracker) Run(ctx context.Context, pollInterval time.Duration) {
+	if watcher, ok := t.src.Windows.(FocusWatcher); ok {
+		slog.Info("Watching focus events", "source", fmt.Sprintf("%T", t.src.Windows))
+		go t.watchFocus(ctx, watcher)
+	}
+
+	// settings passed to Reconfigure before Run
+	select {
+	case s := <-t.settings:
+		t.applySettings(s)
+		pollInterval = s.PollInterval
+	default:
+	}
+
+	// Initial collection
+	if err := t.CollectAndLog(ctx); err != nil {
+		slog.Error("Error collecting initial data", "error", err)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.CollectAndLog(ctx); err != nil {
+				slog.Error("Error collecting data", "error", err)
+			}
+		case s := <-t.settings:
+			t.applySettings(s)
+			pollInterval = s.PollInterval
+			ticker.Reset(pollInterval)
+		case <-t.wake:
+			// (re)start the debounce timer, collecting once events settle
+			debounce = time.After(focusDebounce)
+		case <-debounce:
+			debounce = nil
+			if err := t.CollectAndLog(ctx); err != nil {
+				slog.Error("Error collecting data after focus change", "error", err)
+			}
+			ticker.Reset(pollInterval)
+		case <-ctx.Done():
+			if err := t.RecordStopped(context.WithoutCancel(ctx)); err != nil {
+				slog.Error("Error recording daemon stop", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// Wake schedules a collection (after the focus debounce) outside the regular poll interval.
+func (t *Tracker) Wake() {
+	select {
+	case t.wake <- struct{}{}:
+	default: // a collection is already pending
+	}
+}
+
+func (t *Tracker) watchFocus(ctx context.Context, watcher FocusWatcher) {
+	for {
+		err := watcher.WatchFocus(ctx, func(info WindowInfo) {
+			// the title isn't logged, privacy rules haven't been applied to it yet
+			slog.Debug("Focus changed", "app", info.AppName)
+			t.Wake()
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("Focus watcher stopped, falling back to polling until it reconnects", "error", err)
+		select {
+		case <-time.After(focusRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
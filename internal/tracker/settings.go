@@ -0,0 +1,76 @@
+package tracker
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Settings are the tracker options that can change while it runs, see Reconfigure.
+type Settings struct {
+	PollInterval   time.Duration
+	IdleThreshold  time.Duration
+	StaleThreshold time.Duration
+	// MinGap is the shortest away period recorded as an unaccounted gap when the user returns, 0 records none
+	MinGap  time.Duration
+	Privacy Privacy
+	Titles  Titles
+}
+
+// Titles controls how window titles are normalized before spans are compared and stored. The zero value stores
+// titles as they are.
+type Titles struct {
+	Normalize bool           // apply the built-in rewrites, see builtinTitleRewrites
+	Rewrites  []TitleRewrite // applied in order after the built-in rewrites
+	KeepRaw   bool           // store the title before normalization with new spans
+}
+
+// Privacy limits what is stored about the focused window and the context from integrations. The zero value stores
+// everything.
+type Privacy struct {
+	DropBrowserURLs      bool // keep only the domain of browser tabs
+	DropTerminalCommands bool // don't store the command running in terminals
+	// DetectPrivateWindows stores private browsing windows as private away spans rather than with a redacted title,
+	// see isPrivateWindow
+	DetectPrivateWindows bool
+	Rules                []PrivacyRule // applied in order, the first matching rule wins
+}
+
+// Reconfigure replaces the settings passed to New and Run. They are applied by Run between collections, replacing
+// any settings still pending.
+func (t *Tracker) Reconfigure(s Settings) {
+	for {
+		select {
+		case t.settings <- s:
+			return
+		default:
+			// drop the pending settings in favour of s
+			select {
+			case <-t.settings:
+			default:
+			}
+		}
+	}
+}
+
+// applySettings is called from the Run goroutine, which is the only one reading the settings fields.
+func (t *Tracker) applySettings(s Settings) {
+	t.idleThreshold = s.IdleThreshold
+	t.staleThreshold = s.StaleThreshold
+	t.minGap = s.MinGap
+	t.privacy = s.Privacy
+	t.titles = s.Titles
+
+	slog.Info("Tracker settings applied",
+		"pollInterval", s.PollInterval,
+		"idleThreshold", s.IdleThreshold,
+		"staleThreshold", s.StaleThreshold,
+		"minGap", s.MinGap,
+		"dropBrowserURLs", s.Privacy.DropBrowserURLs,
+		"dropTerminalCommands", s.Privacy.DropTerminalCommands,
+		"detectPrivateWindows", s.Privacy.DetectPrivateWindows,
+		"privacyRules", len(s.Privacy.Rules),
+		"normalizeTitles", s.Titles.Normalize,
+		"titleRewrites", len(s.Titles.Rewrites),
+		"keepRawTitles", s.Titles.KeepRaw,
+	)
+}
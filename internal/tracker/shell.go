@@ -0,0 +1,53 @@
+package tracker
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// maxShells bounds the number of shells remembered, the oldest are forgotten first
+const maxShells = 64
+
+// ShellEvent is reported by the prompt hook printed by `shell-init`, before a command runs and when the prompt
+// returns.
+type ShellEvent struct {
+	PID     int32 // process id of the shell
+	Cwd     string
+	GitRoot string
+	Command string    // empty while at the prompt
+	At      time.Time // set by UpdateShell
+}
+
+// UpdateShell records the latest state of a shell and schedules a collection.
+func (t *Tracker) UpdateShell(ev ShellEvent) {
+	ev.At = t.Now()
+
+	t.mu.Lock()
+	t.shells = slices.DeleteFunc(t.shells, func(s ShellEvent) bool {
+		return s.PID == ev.PID
+	})
+	t.shells = append([]ShellEvent{ev}, t.shells...) // most recent first
+	if len(t.shells) > maxShells {
+		t.shells = t.shells[:maxShells]
+	}
+	t.mu.Unlock()
+
+	// commands and directories stay out of the logs, they're only stored when the privacy settings allow it
+	slog.Debug("Shell event", "pid", ev.PID, "running", ev.Command != "")
+	t.Wake()
+}
+
+// latestShell returns the most recently used shell running inside the process terminalPID.
+func (t *Tracker) latestShell(terminalPID int32) (ShellEvent, bool) {
+	t.mu.Lock()
+	shells := slices.Clone(t.shells)
+	t.mu.Unlock()
+
+	for _, sh := range shells {
+		if isDescendant(sh.PID, terminalPID) {
+			return sh, true
+		}
+	}
+	return ShellEvent{}, false
+}
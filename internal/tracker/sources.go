@@ -0,0 +1,67 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnsupported is returned by sources that have no implementation on the current platform.
+var ErrUnsupported = errors.New("not supported on this platform")
+
+// ErrNotReported is returned by window sources that are told about the focused window, while nothing has told them
+// yet. Unlike an empty window list it doesn't mean missing permissions.
+var ErrNotReported = errors.New("focused window not reported yet")
+
+// WindowInfo describes a single on-screen window
+type WindowInfo struct {
+	AppName     string
+	RawAppName  string
+	WindowTitle string
+	IsActive    bool
+
+	// RawWindowTitle is set by the tracker to the title before normalization, when raw titles are kept
+	RawWindowTitle string
+
+	// AppID identifies the app independent of its display name: the bundle id on macOS, the executable path (or
+	// WM_CLASS / Wayland app id when the process can't be inspected) on Linux.
+	AppID   string
+	PID     int32
+	Display string // name of the display the window is on, if known
+}
+
+// PowerAssertionInfo represents a single power assertion
+type PowerAssertionInfo struct {
+	ProcessName   string
+	PID           int32
+	AssertionType string
+}
+
+// WindowSource lists the open windows, marking the focused one with IsActive.
+type WindowSource interface {
+	GetWindows() ([]WindowInfo, error)
+}
+
+// FocusWatcher is implemented by window sources that can push focus changes instead of being polled.
+// WatchFocus calls fn whenever the focused window (or its title) changes, and blocks until ctx is cancelled or the
+// underlying connection fails.
+type FocusWatcher interface {
+	WatchFocus(ctx context.Context, fn func(WindowInfo)) error
+}
+
+// IdleSource reports the number of seconds since the last user input.
+type IdleSource interface {
+	GetIdleTime() (float64, error)
+}
+
+// PowerSource reports whether any app is keeping the display awake (e.g. a video call).
+type PowerSource interface {
+	HasActivePowerAssertions() (bool, error)
+	GetPowerAssertions() ([]PowerAssertionInfo, error)
+}
+
+// Sources bundles the platform inputs the Tracker polls.
+type Sources struct {
+	Windows WindowSource
+	Idle    IdleSource
+	Power   PowerSource
+}
@@ -0,0 +1,28 @@
+//go:build darwin
+
+package tracker
+
+// DefaultSources returns the macOS sources backed by CoreGraphics, ioreg and IOKit.
+func DefaultSources() (Sources, error) {
+	return Sources{
+		Windows: macWindows{},
+		Idle:    macIdle{},
+		Power:   macPower{},
+	}, nil
+}
+
+type macWindows struct{}
+
+func (macWindows) GetWindows() ([]WindowInfo, error) { return GetWindows() }
+
+type macIdle struct{}
+
+func (macIdle) GetIdleTime() (float64, error) { return GetIdleTime() }
+
+func (macIdle) IsScreenLocked() (bool, error) { return IsScreenLocked() }
+
+type macPower struct{}
+
+func (macPower) HasActivePowerAssertions() (bool, error) { return HasActivePowerAssertions() }
+
+func (macPower) GetPowerAssertions() ([]PowerAssertionInfo, error) { return GetPowerAssertions() }
@@ -0,0 +1,92 @@
+//go:build linux
+
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// DefaultSources picks the Linux sources for the current desktop session.
+func DefaultSources() (Sources, error) {
+	src := Sources{
+		Windows: unsupportedWindows{},
+		Idle:    unsupportedIdle{},
+		Power:   unsupportedPower{},
+	}
+
+	windows, err := linuxWindowSource()
+	if err != nil {
+		return src, err
+	}
+	src.Windows = windows
+
+	if idle := linuxIdleSource(); len(idle) > 0 {
+		src.Idle = idle
+	}
+
+	if conn, err := dbus.SystemBus(); err != nil {
+		slog.Warn("Failed to connect to system bus, inhibitor detection disabled", "error", err)
+	} else {
+		src.Power = NewLogindInhibitors(conn)
+	}
+
+	return src, nil
+}
+
+// linuxWindowSource selects the window source from the session environment.
+// Wayland compositors are checked first because they also set DISPLAY for Xwayland clients.
+func linuxWindowSource() (WindowSource, error) {
+	if sock := os.Getenv("SWAYSOCK"); sock != "" {
+		slog.Info("Using sway window source", "socket", sock)
+		return NewSwayWindows(sock), nil
+	}
+	if signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"); signature != "" {
+		slog.Info("Using Hyprland window source", "instance", signature)
+		return NewHyprlandWindows(signature), nil
+	}
+	if desktop := os.Getenv("XDG_CURRENT_DESKTOP"); os.Getenv("XDG_SESSION_TYPE") == "wayland" &&
+		(strings.Contains(desktop, "GNOME") || strings.Contains(desktop, "KDE")) {
+		conn, err := dbus.SessionBus()
+		if err != nil {
+			return nil, fmt.Errorf("connect to session bus: %w", err)
+		}
+		slog.Info("Using D-Bus window source, requires the companion extension from contrib/", "desktop", desktop)
+		return NewDBusWindows(conn)
+	}
+	if display := os.Getenv("DISPLAY"); display != "" {
+		slog.Info("Using X11 window source", "display", display)
+		return NewX11Windows(display)
+	}
+	return nil, errors.New("no supported window source: none of SWAYSOCK, HYPRLAND_INSTANCE_SIGNATURE or DISPLAY are set")
+}
+
+// linuxIdleSource orders the idle sources for the session, see newFallbackIdle.
+func linuxIdleSource() fallbackIdle {
+	wayland := os.Getenv("XDG_SESSION_TYPE") == "wayland"
+
+	var logind IdleSource
+	if conn, err := dbus.SystemBus(); err != nil {
+		slog.Warn("Failed to connect to system bus, logind idle detection disabled", "error", err)
+	} else {
+		logind = NewLogindIdle(conn)
+	}
+
+	// under Wayland, DISPLAY belongs to Xwayland, which only sees input to X clients
+	var screenSaver IdleSource
+	if display := os.Getenv("DISPLAY"); display != "" && !wayland {
+		x, err := NewX11ScreenSaverIdle(display)
+		if err != nil {
+			slog.Warn("Failed to init X screensaver idle detection", "error", err)
+		} else {
+			screenSaver = x
+		}
+	}
+
+	return newFallbackIdle(screenSaver, logind)
+}
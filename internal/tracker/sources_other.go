@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package tracker
+
+// DefaultSources returns sources that report ErrUnsupported.
+// The package still compiles, so the span logic can be driven with the fakes in fake_sources.go.
+func DefaultSources() (Sources, error) {
+	return Sources{
+		Windows: unsupportedWindows{},
+		Idle:    unsupportedIdle{},
+		Power:   unsupportedPower{},
+	}, nil
+}
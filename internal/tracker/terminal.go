@@ -0,0 +1,152 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Terminal context sources
+const (
+	TerminalShell = "shell" // the shell prompt hook
+	TerminalTmux  = "tmux"  // the active pane of a tmux client
+)
+
+// attachTerminalContext stores what the focused terminal is doing with span: the active pane of a tmux client (for
+// terminal emulators only) or the state of a shell running inside the span's process, whichever was used last. Both are
+// matched to the terminal by walking their parent processes up to the span's PID.
+func (t *Tracker) attachTerminalContext(ctx context.Context, span store.Span) error {
+	if span.Pid == 0 {
+		return nil
+	}
+	terminalPID := int32(span.Pid)
+
+	params := store.UpsertTerminalContextParams{SpanID: span.ID}
+	var usedAt int64
+
+	if sh, ok := t.latestShell(terminalPID); ok {
+		params.Cwd = sh.Cwd
+		params.GitRoot = sh.GitRoot
+		params.Command = sh.Command
+		params.Source = TerminalShell
+		usedAt = sh.At.Unix()
+	}
+
+	// querying tmux forks a process per server, so it's left to terminal emulators
+	if isTerminalApp(span) {
+		pane, err := activeTmuxPane(ctx, terminalPID)
+		if err != nil {
+			slog.Debug("Failed to query tmux", "error", err)
+		}
+		if pane != nil && pane.Activity >= usedAt {
+			params.Cwd = pane.Cwd
+			params.GitRoot = GitRoot(pane.Cwd)
+			params.Command = pane.Command
+			params.Source = TerminalTmux
+		}
+	}
+
+	if params.Cwd == "" {
+		return nil
+	}
+	if t.privacy.DropTerminalCommands {
+		params.Command = ""
+	}
+	// transitional, like the browser tab table (see Attribute)
+	if err := t.db.UpsertTerminalContext(ctx, params); err != nil {
+		return fmt.Errorf("upsert terminal context: %w", err)
+	}
+
+	attrs := []Attribute{StringAttr(AttrCwd, params.Cwd), StringAttr(AttrCommand, params.Command)}
+	if params.GitRoot != "" {
+		attrs = append(attrs, StringAttr(AttrGitRoot, params.GitRoot))
+		if branch := GitBranch(params.GitRoot); branch != "" {
+			attrs = append(attrs, StringAttr(AttrGitBranch, branch))
+		}
+	}
+	return t.setAttributes(ctx, span.ID, attrs...)
+}
+
+// terminalApps matches the names of terminal emulators, e.g. Terminal, iTerm2, gnome-terminal, WezTerm, the base name
+// of their executables on Linux and their macOS bundle ids and Linux app ids. Names are matched whole, "term" alone
+// is part of too many other apps.
+var terminalApps = regexp.MustCompile(`(?i)(?:^|/)(?:` +
+	`terminal|iterm2?|ghostty|kitty|alacritty|wezterm(?:-gui)?|warp(?:-terminal)?|tabby|hyper|` +
+	`gnome-terminal(?:-server)?|kgx|ptyxis|u?xterm|foot(?:client)?|konsole|tilix|u?rxvt(?:-unicode)?|` +
+	`xfce4-terminal|mate-terminal|lxterminal|qterminal|terminator|terminology|st(?:-256color)?|sakura|guake|` +
+	`tilda|yakuake|blackbox` +
+	`)$|^(?:com\.apple\.terminal|com\.googlecode\.iterm2|com\.mitchellh\.ghostty|net\.kovidgoyal\.kitty|` +
+	`(?:org|io)\.alacritty|com\.github\.wez\.wezterm|org\.wezfurlong\.wezterm|dev\.warp\.warp|org\.tabby|` +
+	`co\.zeit\.hyper|org\.gnome\.(?:terminal|console|ptyxis)|org\.kde\.konsole|com\.gexperts\.tilix|` +
+	`com\.raggesilver\.blackbox)`)
+
+// isTerminalApp reports whether span is of a terminal emulator.
+func isTerminalApp(span store.Span) bool {
+	return terminalApps.MatchString(span.AppName) || terminalApps.MatchString(span.AppID)
+}
+
+// isDescendant reports whether ancestor is a parent, grandparent, etc. of pid.
+func isDescendant(pid, ancestor int32) bool {
+	for range 32 { // bounded, the process tree can change while walking it
+		ppid, err := parentPID(pid)
+		if err != nil || ppid <= 1 {
+			return false
+		}
+		if ppid == ancestor {
+			return true
+		}
+		pid = ppid
+	}
+	return false
+}
+
+// GitRoot returns the top level of the git repository containing dir (the directory holding `.git`), or "" if dir
+// isn't inside one. Unlike `git rev-parse --show-toplevel` it needs no subprocess, so the prompt hook stays fast.
+func GitRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// GitBranch returns the branch checked out in the repository at root (as returned by GitRoot), or "" when HEAD is
+// detached or can't be read. Worktrees, where `.git` is a file pointing to the git dir, are followed.
+func GitBranch(root string) string {
+	gitDir := filepath.Join(root, ".git")
+	if data, err := os.ReadFile(gitDir); err == nil {
+		// "gitdir: /path/to/repo/.git/worktrees/name"
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return ""
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(root, target)
+		}
+		gitDir = target
+	}
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+	if !ok {
+		return "" // detached HEAD
+	}
+	return branch
+}
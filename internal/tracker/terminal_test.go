@@ -0,0 +1,43 @@
+package tracker
+
+import (
+	"testing"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+func TestIsTerminalApp(t *testing.T) {
+	tests := []struct {
+		app, appID string
+		want       bool
+	}{
+		{app: "Terminal", appID: "com.apple.Terminal", want: true},
+		{app: "iTerm2", appID: "com.googlecode.iterm2", want: true},
+		{app: "Ghostty", appID: "com.mitchellh.ghostty", want: true},
+		{app: "WezTerm", appID: "com.github.wez.wezterm", want: true},
+		{app: "Warp", appID: "dev.warp.Warp-Stable", want: true},
+		{app: "Terminal", appID: "org.gnome.Terminal", want: true},
+		{app: "Console", appID: "org.gnome.Console", want: true},
+		{app: "Gnome-terminal", appID: "/usr/libexec/gnome-terminal-server", want: true},
+		{app: "XTerm", appID: "/usr/bin/xterm", want: true},
+		{app: "URxvt", appID: "/usr/bin/urxvt", want: true},
+		{app: "foot", appID: "footclient", want: true},
+		{app: "kitty", appID: "/usr/bin/kitty", want: true},
+		{app: "Konsole", appID: "org.kde.konsole", want: true},
+		{app: "Xfce4-terminal", appID: "/usr/bin/xfce4-terminal", want: true},
+		{app: "Editor", appID: "/opt/wezterm/wezterm-gui", want: true},
+		{app: "Console", appID: "com.apple.Console"},
+		{app: "Terminus Notes", appID: "/usr/bin/terminus"},
+		{app: "Intermission", appID: "com.example.intermission"},
+		{app: "Footnotes", appID: "/usr/bin/footnotes"},
+		{app: "Kitty Cats", appID: "com.example.kittycats"},
+		{app: "Firefox", appID: "/usr/lib/firefox/firefox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.app+" "+tt.appID, func(t *testing.T) {
+			if got := isTerminalApp(store.Span{AppName: tt.app, AppID: tt.appID}); got != tt.want {
+				t.Errorf("isTerminalApp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
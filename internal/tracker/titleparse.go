@@ -0,0 +1,165 @@
+package tracker
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// Attribute keys filled by the title parsers
+const (
+	AttrProject   = "project"
+	AttrFile      = "file"
+	AttrChannel   = "channel"
+	AttrWorkspace = "workspace"
+	AttrDocument  = "document"
+	AttrMeeting   = "meeting"
+)
+
+// TitleParser extracts structured fields from the window titles of an app. Each named group of the first matching
+// pattern becomes a field, e.g. (?P<project>...), empty groups are skipped.
+type TitleParser struct {
+	Name     string
+	App      *regexp.Regexp // matched against the app name and app id
+	Patterns []*regexp.Regexp
+}
+
+// Parse returns the fields of title, or nil if no pattern matches.
+func (p TitleParser) Parse(title string) map[string]string {
+	for _, pattern := range p.Patterns {
+		m := pattern.FindStringSubmatch(title)
+		if m == nil {
+			continue
+		}
+		fields := make(map[string]string)
+		for i, name := range pattern.SubexpNames() {
+			if name != "" && m[i] != "" {
+				fields[name] = m[i]
+			}
+		}
+		return fields
+	}
+	return nil
+}
+
+// browserApp matches the browser app names, the base name of their executables on Linux and their macOS bundle ids.
+// Names are matched whole, "Arc" and "Edge" are substrings of too many other apps.
+var browserApp = regexp.MustCompile(`(?i)(?:^|/)(?:` +
+	`(?:google[ -])?chrome(?:[ -](?:beta|dev|canary|unstable))?|chromium(?:-browser)?|` +
+	`(?:mozilla )?firefox(?:[ -](?:developer edition|nightly|esr))?|safari(?: technology preview)?|` +
+	`microsoft[ -]edge(?:[ -](?:beta|dev|canary|stable))?|msedge|brave(?:[ -]browser)?|arc|` +
+	`vivaldi(?:-stable|-bin)?|opera` +
+	`)$|^(?:com\.google\.chrome|org\.chromium\.chromium|org\.mozilla\.(?:firefox|nightly)|com\.apple\.safari|` +
+	`com\.microsoft\.edgemac|com\.brave\.browser|company\.thebrowser\.browser|com\.vivaldi\.vivaldi|` +
+	`com\.operasoftware\.opera)`)
+
+// titleParsers is the registry of known title formats, the first parser matching the app and title is used.
+var titleParsers = []TitleParser{
+	{
+		// "mac-time-tracker – tracker.go" or "mac-time-tracker [~/src/mac-time-tracker] – internal/tracker/tracker.go"
+		Name: "jetbrains",
+		App: regexp.MustCompile(
+			`(?i)jetbrains|intellij|goland|pycharm|webstorm|phpstorm|rubymine|clion|rider|datagrip|rustrover|android studio`,
+		),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<project>.+?)(?: \[[^\]]*\])? – (?P<file>.+)$`),
+		},
+	},
+	{
+		// "tracker.go — mac-time-tracker — Visual Studio Code" (" - " on Linux), or without the file
+		Name: "vscode",
+		App:  regexp.MustCompile(`(?i)code|cursor|windsurf|vscodium`),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(
+				`^(?:(?P<file>.+?) [—-] )?(?P<project>.+?) [—-] (?:Visual Studio Code(?: - Insiders)?|Cursor|Windsurf|VSCodium)$`,
+			),
+		},
+	},
+	{
+		// "general (Channel) - Acme - Slack", "Jane Doe (DM) - Acme - Slack" or the older "Slack | general | Acme"
+		Name: "slack",
+		App:  regexp.MustCompile(`(?i)^slack$|com\.tinyspeck\.slackmacgap`),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<channel>.+?)(?: \((?:Channel|Private channel|DM|Group DM)\))? - (?P<workspace>.+?) - Slack$`),
+			regexp.MustCompile(`^Slack \| (?P<channel>.+?) \| (?P<workspace>.+)$`),
+		},
+	},
+	{
+		// "Zoom Meeting - Weekly sync" (the topic isn't always in the title)
+		Name: "zoom",
+		App:  regexp.MustCompile(`(?i)zoom`),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^Zoom (?:Meeting|Webinar)(?: - (?P<meeting>.+))?$`),
+		},
+	},
+	{
+		// "Meet - abc-defg-hij - Google Chrome"
+		Name: "google-meet",
+		App:  browserApp,
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^Meet - (?P<meeting>[a-z]{3}-[a-z]{4}-[a-z]{3})\b`),
+		},
+	},
+	{
+		// "Quarterly report - Google Docs - Google Chrome"
+		Name: "google-docs",
+		App:  browserApp,
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<document>.+?) - Google (?:Docs|Sheets|Slides)\b`),
+		},
+	},
+	{
+		// "report.odt - LibreOffice Writer"
+		Name: "libreoffice",
+		App:  regexp.MustCompile(`(?i)libreoffice|soffice`),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<document>.+?) - LibreOffice \w+$`),
+		},
+	},
+	{
+		// Office and iWork on macOS only show the document name
+		Name: "office",
+		App: regexp.MustCompile(
+			`(?i)^(?:microsoft )?(?:word|excel|powerpoint)$|^(?:pages|numbers|keynote)$|com\.microsoft\.(?:word|excel|powerpoint)|com\.apple\.iwork`,
+		),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<document>.+)$`),
+		},
+	},
+	{
+		// "Design system – Figma"
+		Name: "figma",
+		App:  regexp.MustCompile(`(?i)figma`),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^(?P<document>.+?) – Figma$`),
+		},
+	},
+}
+
+// ParseTitle returns the fields of a window title using the first registered parser that matches the app and title.
+func ParseTitle(appName, appID, title string) map[string]string {
+	for _, parser := range titleParsers {
+		if !parser.App.MatchString(appName) && (appID == "" || !parser.App.MatchString(appID)) {
+			continue
+		}
+		if fields := parser.Parse(title); fields != nil {
+			return fields
+		}
+	}
+	return nil
+}
+
+// attachTitleAttributes stores the fields parsed from the span's window title as span attributes.
+func (t *Tracker) attachTitleAttributes(ctx context.Context, span store.Span) error {
+	if span.WindowTitle == RedactedTitle {
+		return nil
+	}
+
+	fields := ParseTitle(span.AppName, span.AppID, span.WindowTitle)
+	attrs := make([]Attribute, 0, len(fields))
+	for key, value := range fields {
+		attrs = append(attrs, StringAttr(key, value))
+	}
+	return t.setAttributes(ctx, span.ID, attrs...)
+}
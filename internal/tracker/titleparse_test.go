@@ -0,0 +1,209 @@
+package tracker
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		app   string
+		appID string
+		title string
+		want  map[string]string
+	}{
+		{
+			name:  "GoLand",
+			app:   "GoLand",
+			appID: "com.jetbrains.goland",
+			title: "mac-time-tracker – tracker.go",
+			want:  map[string]string{AttrProject: "mac-time-tracker", AttrFile: "tracker.go"},
+		},
+		{
+			name:  "IntelliJ IDEA with the project path",
+			app:   "jetbrains-idea",
+			appID: "/opt/idea/bin/idea",
+			title: "billing [~/src/billing] – src/main/java/Invoice.java",
+			want:  map[string]string{AttrProject: "billing", AttrFile: "src/main/java/Invoice.java"},
+		},
+		{
+			name:  "VS Code on macOS",
+			app:   "Code",
+			appID: "com.microsoft.VSCode",
+			title: "tracker.go — mac-time-tracker — Visual Studio Code",
+			want:  map[string]string{AttrProject: "mac-time-tracker", AttrFile: "tracker.go"},
+		},
+		{
+			name:  "VS Code on Linux",
+			app:   "Code",
+			appID: "/usr/share/code/code",
+			title: "tracker.go - mac-time-tracker - Visual Studio Code",
+			want:  map[string]string{AttrProject: "mac-time-tracker", AttrFile: "tracker.go"},
+		},
+		{
+			name:  "VS Code Insiders without a file",
+			app:   "Code - Insiders",
+			title: "mac-time-tracker - Visual Studio Code - Insiders",
+			want:  map[string]string{AttrProject: "mac-time-tracker"},
+		},
+		{
+			name:  "Cursor",
+			app:   "Cursor",
+			title: "README.md — mac-time-tracker — Cursor",
+			want:  map[string]string{AttrProject: "mac-time-tracker", AttrFile: "README.md"},
+		},
+		{
+			name:  "VS Code welcome page",
+			app:   "Code",
+			title: "Welcome",
+		},
+		{
+			name:  "Slack channel",
+			app:   "Slack",
+			appID: "com.tinyspeck.slackmacgap",
+			title: "general (Channel) - Acme - Slack",
+			want:  map[string]string{AttrChannel: "general", AttrWorkspace: "Acme"},
+		},
+		{
+			name:  "Slack DM",
+			app:   "Slack",
+			title: "Jane Doe (DM) - Acme - Slack",
+			want:  map[string]string{AttrChannel: "Jane Doe", AttrWorkspace: "Acme"},
+		},
+		{
+			name:  "older Slack title",
+			app:   "Slack",
+			title: "Slack | random | Acme",
+			want:  map[string]string{AttrChannel: "random", AttrWorkspace: "Acme"},
+		},
+		{
+			name:  "Slack in a browser",
+			app:   "Google Chrome",
+			title: "general (Channel) - Acme - Slack - Google Chrome",
+		},
+		{
+			name:  "Zoom meeting with a topic",
+			app:   "zoom.us",
+			appID: "us.zoom.xos",
+			title: "Zoom Meeting - Weekly sync",
+			want:  map[string]string{AttrMeeting: "Weekly sync"},
+		},
+		{
+			name:  "Zoom meeting without a topic",
+			app:   "zoom",
+			title: "Zoom Meeting",
+			want:  map[string]string{},
+		},
+		{
+			name:  "Google Meet in Chrome",
+			app:   "Google Chrome",
+			appID: "com.google.Chrome",
+			title: "Meet - abc-defg-hij - Google Chrome",
+			want:  map[string]string{AttrMeeting: "abc-defg-hij"},
+		},
+		{
+			name:  "Google Meet in Firefox on Linux",
+			app:   "Firefox",
+			appID: "/usr/lib/firefox/firefox",
+			title: "Meet - abc-defg-hij — Mozilla Firefox",
+			want:  map[string]string{AttrMeeting: "abc-defg-hij"},
+		},
+		{
+			name:  "Google Meet in Arc",
+			app:   "Arc",
+			appID: "company.thebrowser.Browser",
+			title: "Meet - abc-defg-hij",
+			want:  map[string]string{AttrMeeting: "abc-defg-hij"},
+		},
+		{
+			name:  "Google Docs in Edge",
+			app:   "Microsoft Edge",
+			title: "Quarterly report - Google Docs - Microsoft Edge",
+			want:  map[string]string{AttrDocument: "Quarterly report"},
+		},
+		{
+			name:  "Google Sheets in Brave by bundle id",
+			app:   "Brave",
+			appID: "com.brave.Browser",
+			title: "Budget 2026 - Google Sheets - Brave",
+			want:  map[string]string{AttrDocument: "Budget 2026"},
+		},
+		{
+			name:  "browser title of another app",
+			app:   "Search",
+			title: "Meet - abc-defg-hij",
+		},
+		{
+			name:  "browser title of an app containing a browser name",
+			app:   "Knowledge",
+			appID: "com.example.knowledge",
+			title: "Quarterly report - Google Docs",
+		},
+		{
+			name:  "LibreOffice",
+			app:   "libreoffice-writer",
+			appID: "/usr/lib/libreoffice/program/soffice.bin",
+			title: "report.odt - LibreOffice Writer",
+			want:  map[string]string{AttrDocument: "report.odt"},
+		},
+		{
+			name:  "Word",
+			app:   "Microsoft Word",
+			appID: "com.microsoft.Word",
+			title: "Proposal.docx",
+			want:  map[string]string{AttrDocument: "Proposal.docx"},
+		},
+		{
+			name:  "Keynote",
+			app:   "Keynote",
+			title: "All hands",
+			want:  map[string]string{AttrDocument: "All hands"},
+		},
+		{
+			name:  "Figma",
+			app:   "Figma",
+			title: "Design system – Figma",
+			want:  map[string]string{AttrDocument: "Design system"},
+		},
+		{
+			name:  "unknown app",
+			app:   "Terminal",
+			title: "~/src — zsh — 80×24",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseTitle(tt.app, tt.appID, tt.title)
+			if !maps.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("ParseTitle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrowserApp(t *testing.T) {
+	browsers := []string{
+		"Google Chrome", "Google Chrome Canary", "google-chrome", "/opt/google/chrome/chrome", "Chromium",
+		"chromium-browser", "Firefox", "firefox-esr", "Firefox Developer Edition", "/usr/lib/firefox/firefox", "Safari",
+		"Microsoft Edge", "microsoft-edge-stable", "/opt/microsoft/msedge/msedge", "Brave Browser", "brave-browser",
+		"Arc", "Vivaldi", "vivaldi-stable", "Opera", "com.google.Chrome", "com.google.Chrome.canary",
+		"org.mozilla.firefox", "com.apple.Safari", "com.microsoft.edgemac", "com.brave.Browser",
+		"company.thebrowser.Browser", "com.vivaldi.Vivaldi", "com.operasoftware.Opera",
+	}
+	for _, app := range browsers {
+		if !browserApp.MatchString(app) {
+			t.Errorf("browserApp doesn't match %q", app)
+		}
+	}
+
+	others := []string{
+		"Search", "Knowledge", "Research", "Archive Utility", "Arcade", "Edge Impulse", "Chrome Remote Desktop",
+		"Firefox Profile Manager", "Operator", "/usr/bin/sarcasm", "com.example.knowledge",
+	}
+	for _, app := range others {
+		if browserApp.MatchString(app) {
+			t.Errorf("browserApp matches %q", app)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tmuxTimeout = 2 * time.Second
+
+// tmuxPane is the active pane of a tmux client
+type tmuxPane struct {
+	Cwd      string
+	Command  string
+	Activity int64 // Unix timestamp of the client's last input
+}
+
+// tmuxPath finds the tmux binary. The daemon may run with a minimal PATH (e.g. from launchd), so the usual package
+// manager locations are tried as well.
+func tmuxPath() (string, error) {
+	if path, err := exec.LookPath("tmux"); err == nil {
+		return path, nil
+	}
+	for _, path := range []string{"/opt/homebrew/bin/tmux", "/usr/local/bin/tmux", "/usr/bin/tmux"} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", exec.ErrNotFound
+}
+
+// tmuxSockets lists the server sockets of the current user, e.g. /tmp/tmux-501/default.
+func tmuxSockets() []string {
+	dir := os.Getenv("TMUX_TMPDIR")
+	if dir == "" {
+		dir = "/tmp"
+	}
+	sockets, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("tmux-%d", os.Getuid()), "*"))
+	return sockets
+}
+
+// activeTmuxPane returns the active pane of the most recently used tmux client running inside the process
+// terminalPID, or nil if the terminal isn't running tmux.
+func activeTmuxPane(ctx context.Context, terminalPID int32) (*tmuxPane, error) {
+	sockets := tmuxSockets()
+	if len(sockets) == 0 {
+		return nil, nil
+	}
+	tmux, err := tmuxPath()
+	if err != nil {
+		return nil, nil // sockets without a tmux binary, e.g. left over after uninstalling it
+	}
+
+	var active *tmuxPane
+	for _, socket := range sockets {
+		out, err := runTmux(ctx, tmux, socket, "list-clients", "-F", "#{client_pid}\t#{session_id}\t#{client_activity}")
+		if err != nil {
+			continue // no server is listening on stale sockets
+		}
+
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.Split(line, "\t")
+			if len(fields) != 3 {
+				continue
+			}
+			clientPID, _ := strconv.ParseInt(fields[0], 10, 32)
+			activity, _ := strconv.ParseInt(fields[2], 10, 64)
+			if clientPID <= 0 || (active != nil && activity < active.Activity) {
+				continue
+			}
+			if !isDescendant(int32(clientPID), terminalPID) {
+				continue
+			}
+
+			pane, err := activeTmuxSessionPane(ctx, tmux, socket, fields[1])
+			if err != nil {
+				return nil, err
+			}
+			if pane != nil {
+				pane.Activity = activity
+				active = pane
+			}
+		}
+	}
+	return active, nil
+}
+
+// activeTmuxSessionPane returns the active pane of the active window in sessionID.
+func activeTmuxSessionPane(ctx context.Context, tmux, socket, sessionID string) (*tmuxPane, error) {
+	out, err := runTmux(ctx, tmux, socket, "list-panes", "-s", "-t", sessionID,
+		"-F", "#{window_active}#{pane_active}\t#{pane_current_command}\t#{pane_current_path}",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseActivePane(out), nil
+}
+
+// parseActivePane returns the pane marked active in its window and session from the list-panes output of
+// activeTmuxSessionPane, or nil if there is none.
+func parseActivePane(out string) *tmuxPane {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) == 3 && fields[0] == "11" {
+			return &tmuxPane{
+				Command: fields[1],
+				Cwd:     fields[2],
+			}
+		}
+	}
+	return nil
+}
+
+func runTmux(ctx context.Context, tmux, socket string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, tmuxTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, tmux, append([]string{"-S", socket}, args...)...).Output()
+	if err != nil {
+		return "", fmt.Errorf("tmux %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
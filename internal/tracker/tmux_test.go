@@ -0,0 +1,42 @@
+package tracker
+
+import "testing"
+
+func TestParseActivePane(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want *tmuxPane
+	}{
+		{
+			name: "active pane of active window",
+			out:  "10\tzsh\t/home/me\n01\tvim\t/tmp\n11\tnvim\t/home/me/src/app",
+			want: &tmuxPane{Command: "nvim", Cwd: "/home/me/src/app"},
+		},
+		{
+			name: "path with tabs",
+			out:  "11\tzsh\t/home/me/odd\tdir",
+			want: &tmuxPane{Command: "zsh", Cwd: "/home/me/odd\tdir"},
+		},
+		{
+			name: "no active pane",
+			out:  "10\tzsh\t/home/me\n01\tvim\t/tmp",
+		},
+		{
+			name: "empty output",
+			out:  "",
+		},
+		{
+			name: "malformed line",
+			out:  "11\tzsh",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseActivePane(tt.out)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parseActivePane() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,54 +2,140 @@ package tracker
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
 )
 
-var prevIdleState = false
-var prevPowerState = false
+// Tracker polls its Sources and records the focused window as spans in the store.
+type Tracker struct {
+	db             *store.Queries
+	src            Sources
+	idleThreshold  time.Duration
+	staleThreshold time.Duration
+	privacy        Privacy
+	titles         Titles
+	minGap         time.Duration
+
+	// Now returns the current time. It defaults to time.Now and can be replaced to drive the tracker with a fake clock.
+	Now func() time.Time
+
+	prevIdleState  bool
+	prevPowerState bool
+
+	// openSpan is the span as the tracker last wrote it. Only that row is extended: once it's edited or deleted, the
+	// next poll starts a new span rather than overwriting the edit.
+	openSpan store.Span
+
+	// wake triggers a collection from outside the poll loop (focus events, browser tab updates)
+	wake chan struct{}
+	// settings passes new settings to the Run goroutine
+	settings chan Settings
+
+	mu         sync.Mutex
+	browserTab *BrowserTab  // latest tab reported by the browser extension
+	shells     []ShellEvent // latest event of each shell reported by the prompt hook, most recent first
+
+	// pause state and the outcome of the latest collection, reported by Status
+	paused      bool
+	pausedAt    time.Time
+	pausedUntil time.Time // zero while paused until resumed
+	lastPoll    time.Time
+	lastErr     string
+	lastErrAt   time.Time
+}
+
+// New creates a Tracker that reads from src and writes spans to db.
+func New(db *store.Queries, src Sources, idleThreshold, staleThreshold time.Duration) *Tracker {
+	return &Tracker{
+		db:             db,
+		src:            src,
+		idleThreshold:  idleThreshold,
+		staleThreshold: staleThreshold,
+		Now:            time.Now,
+		wake:           make(chan struct{}, 1),
+		settings:       make(chan Settings, 1),
+	}
+}
 
 // CollectAndLog collects the current window state and logs it to the store.
 // This implements the polling logic as specified in logic.md
-func CollectAndLog(ctx context.Context, db *store.Queries, idleThreshold, staleThreshold time.Duration) error {
+func (t *Tracker) CollectAndLog(ctx context.Context) error {
+	now := t.Now()
+	err := t.collectAndLog(ctx, now)
+	t.recordPoll(now, err)
+	return err
+}
+
+func (t *Tracker) collectAndLog(ctx context.Context, nowTime time.Time) error {
+	now := nowTime.Unix()
+
+	// record sleep or downtime since the last poll
+	if err := t.recordGap(ctx, now); err != nil {
+		return fmt.Errorf("record gap error: %w", err)
+	}
+
+	// tracking paused from the control socket
+	if pausedAt, paused := t.pausedSince(nowTime); paused {
+		return t.saveAway(ctx, AwayPaused, ReasonPaused, min(pausedAt.Unix(), now), now)
+	}
+
+	// lock check
+	if lockSource, ok := t.src.Idle.(LockSource); ok {
+		locked, err := lockSource.IsScreenLocked()
+		if err != nil {
+			slog.Warn("Failed to check screen lock", "error", err)
+		} else if locked {
+			return t.saveAway(ctx, AwayLocked, ReasonScreenLocked, now, now)
+		}
+	}
+
 	// idle check
-	idleSeconds, err := GetIdleTime()
+	idleSeconds, err := t.src.Idle.GetIdleTime()
 	if err != nil {
 		return fmt.Errorf("idle time error: %w", err)
 	}
-	isIdle := idleSeconds > idleThreshold.Seconds()
+	isIdle := idleSeconds > t.idleThreshold.Seconds()
 
 	// Check if we should consider the user idle
-	hasPowerAssertions, err := HasActivePowerAssertions()
+	hasPowerAssertions, err := t.src.Power.HasActivePowerAssertions()
 	if err != nil {
 		slog.Warn("Failed to check power assertions", "error", err)
 	}
 
 	defer func() {
-		prevIdleState = isIdle
-		prevPowerState = hasPowerAssertions
+		t.prevIdleState = isIdle
+		t.prevPowerState = hasPowerAssertions
 	}()
 
-	if isIdle != prevIdleState || hasPowerAssertions != prevPowerState {
+	if isIdle != t.prevIdleState || hasPowerAssertions != t.prevPowerState {
 		slog.Debug("Idle changed",
 			"isIdle", isIdle,
-			"threshold", idleThreshold.Seconds(),
+			"threshold", t.idleThreshold.Seconds(),
 			"idleSeconds", idleSeconds,
 			"hasPowerAssertions", hasPowerAssertions,
 		)
 	}
 
 	if isIdle && !hasPowerAssertions {
-		return nil
+		// the user actually left at the last input, not when the threshold was crossed
+		idleStart, err := t.trimToLastInput(ctx, now-int64(idleSeconds))
+		if err != nil {
+			return fmt.Errorf("trim span error: %w", err)
+		}
+		return t.saveAway(ctx, AwayIdle, ReasonIdleThreshold, idleStart, now)
 	}
 
 	// get open windows
-	windows, err := GetWindows()
+	windows, err := t.src.Windows.GetWindows()
+	if errors.Is(err, ErrNotReported) {
+		slog.Debug("Waiting for the focused window to be reported")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("window list error: %w", err)
 	}
@@ -59,66 +145,120 @@ func CollectAndLog(ctx context.Context, db *store.Queries, idleThreshold, staleT
 	}
 
 	// get active app and window
-	activeApp := ""
-	activeWindow := ""
+	var active WindowInfo
 	for _, window := range windows {
 		if window.IsActive {
-			activeApp = window.AppName
-			activeWindow = window.WindowTitle
+			active = window
 			break
 		}
 	}
-	if activeApp == "" || activeWindow == "" {
-		slog.Warn("App or Window name not found", "app", activeApp, "window", activeWindow)
+	if active.AppName == "" || active.WindowTitle == "" {
+		slog.Warn("App or Window name not found", "app", active.AppName, "window", active.WindowTitle)
 		return nil
 	}
 
+	// privacy rules run before anything about the window is stored
+	active, reason := t.filterWindow(active)
+	if reason != "" {
+		return t.saveAway(ctx, AwayPrivate, reason, now, now)
+	}
+	active = t.normalizeTitle(active)
+
+	// the user is back, ask what they were doing while away
+	if err := t.recordUnaccountedGap(ctx, now); err != nil {
+		return fmt.Errorf("record unaccounted gap error: %w", err)
+	}
+
 	// at this point we have a valid active app and window and are not idling
-	err = saveFocused(ctx, db, activeApp, activeWindow, staleThreshold)
+	span, err := t.saveFocused(ctx, active, now)
 	if err != nil {
 		return fmt.Errorf("save focused window error: %w", err)
 	}
 
+	if err := t.enrichSpan(ctx, span); err != nil {
+		return fmt.Errorf("enrich span error: %w", err)
+	}
+
 	return nil
 }
 
-func saveFocused(ctx context.Context, db *store.Queries, activeApp, activeWindow string, staleThreshold time.Duration) error {
-	latestSpan, err := db.SelectLatestSpan(ctx)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("select latest span: %w", err)
+// enrichSpan attaches the fields parsed from the window title and the context reported by integrations (browser
+// extension, shell hook) to the focused span.
+func (t *Tracker) enrichSpan(ctx context.Context, span store.Span) error {
+	if err := t.attachTitleAttributes(ctx, span); err != nil {
+		return err
+	}
+	if err := t.attachBrowserTab(ctx, span); err != nil {
+		return err
+	}
+	if err := t.attachTerminalContext(ctx, span); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Tracker) saveFocused(ctx context.Context, active WindowInfo, now int64) (store.Span, error) {
+	latestSpan, latestAway, err := t.latestActivity(ctx)
+	if err != nil {
+		return store.Span{}, err
 	}
 
 	hasPrevious := latestSpan.ID > 0
-	spanMatch := latestSpan.AppName == activeApp && latestSpan.WindowTitle == activeWindow
-	spanStale := time.Now().Unix()-latestSpan.EndAt > int64(staleThreshold.Seconds())
+	spanMatch := latestSpan.AppName == active.AppName &&
+		latestSpan.AppID == active.AppID &&
+		latestSpan.WindowTitle == active.WindowTitle
+	// an away span recorded after the latest span means the user was idle, locked or asleep in between
+	spanStale := now-latestSpan.EndAt > int64(t.staleThreshold.Seconds()) || latestAway.EndAt > latestSpan.EndAt
 
-	// update span (only if the previous span exists, matches and is not stale)
-	if hasPrevious && spanMatch && !spanStale {
-		latestSpan, err = db.UpdateSpan(ctx, store.UpdateSpanParams{
+	// update span (only if the previous span exists, is still as the tracker wrote it, matches and is not stale)
+	if hasPrevious && t.owns(latestSpan) && spanMatch && !spanStale {
+		latestSpan, err = t.db.UpdateSpan(ctx, store.UpdateSpanParams{
 			ID:    latestSpan.ID,
-			EndAt: time.Now().Unix(),
+			EndAt: now,
 		})
 		if err != nil {
-			return fmt.Errorf("update span: %w", err)
+			return store.Span{}, fmt.Errorf("update span: %w", err)
 		}
 
-		slog.Debug("Updated span", "app", activeApp, "window", activeWindow)
+		slog.Debug("Updated span", "app", active.AppName, "window", active.WindowTitle)
 
-		return nil
+		t.openSpan = latestSpan
+		return latestSpan, nil
+	}
+
+	// on a context switch, close the previous span at the moment of the switch rather than at the last poll, unless it
+	// was edited since
+	if hasPrevious && t.owns(latestSpan) && !spanStale {
+		if _, err := t.db.UpdateSpan(ctx, store.UpdateSpanParams{
+			ID:    latestSpan.ID,
+			EndAt: now,
+		}); err != nil {
+			return store.Span{}, fmt.Errorf("close previous span: %w", err)
+		}
 	}
 
 	// otherwise create a new span
-	latestSpan, err = db.InsertSpan(ctx, store.InsertSpanParams{
-		AppName:     activeApp,
-		WindowTitle: activeWindow,
-		StartAt:     time.Now().Unix(),
-		EndAt:       time.Now().Unix(),
+	latestSpan, err = t.db.InsertSpan(ctx, store.InsertSpanParams{
+		AppName:        active.AppName,
+		WindowTitle:    active.WindowTitle,
+		StartAt:        now,
+		EndAt:          now,
+		AppID:          active.AppID,
+		Pid:            int64(active.PID),
+		Display:        active.Display,
+		RawWindowTitle: active.RawWindowTitle,
 	})
 	if err != nil {
-		return fmt.Errorf("insert span: %w", err)
+		return store.Span{}, fmt.Errorf("insert span: %w", err)
 	}
 
-	slog.Debug("New span", "app", activeApp, "window", activeWindow)
+	slog.Debug("New span", "app", active.AppName, "appID", active.AppID, "window", active.WindowTitle)
 
-	return nil
+	t.openSpan = latestSpan
+	return latestSpan, nil
+}
+
+// owns reports whether span is the row the tracker last wrote, unchanged since.
+func (t *Tracker) owns(span store.Span) bool {
+	return span.ID == t.openSpan.ID && span.EndAt == t.openSpan.EndAt
 }
@@ -0,0 +1,194 @@
+package tracker
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/spanedit"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// poll is the state of the sources at one CollectAndLog call, at seconds since the start of the test.
+type poll struct {
+	at     int64
+	app    string
+	idle   float64
+	power  bool
+	locked bool
+
+	notReported bool // the window source wasn't told about the focused window yet
+
+	// edit changes the recorded spans before the poll, as the web UI would. start is the unix time of second 0.
+	edit func(ctx context.Context, db *store.Queries, start int64) error
+}
+
+// interval is a recorded span (of app) or away span (of kind), as [start, end] seconds since the start of the test.
+type interval struct {
+	name       string
+	start, end int64
+}
+
+func TestCollectAndLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		polls     []poll
+		wantSpans []interval
+		wantAways []interval
+	}{
+		{
+			name:      "same window extends the span",
+			polls:     []poll{{at: 0, app: "Code"}, {at: 5, app: "Code"}, {at: 10, app: "Code"}},
+			wantSpans: []interval{{"Code", 0, 10}},
+		},
+		{
+			name:      "context switch closes the previous span at the switch",
+			polls:     []poll{{at: 0, app: "Code"}, {at: 5, app: "Code"}, {at: 10, app: "Firefox"}},
+			wantSpans: []interval{{"Code", 0, 10}, {"Firefox", 10, 10}},
+		},
+		{
+			name:      "idle trims the span to the last input",
+			polls:     []poll{{at: 0, app: "Code"}, {at: 50, app: "Code"}, {at: 100, app: "Code", idle: 70}},
+			wantSpans: []interval{{"Code", 0, 30}},
+			wantAways: []interval{{AwayIdle, 30, 100}},
+		},
+		{
+			name:      "span ended before the last input isn't trimmed",
+			polls:     []poll{{at: 0, app: "Code"}, {at: 5, app: "Code"}, {at: 100, app: "Code", idle: 90}},
+			wantSpans: []interval{{"Code", 0, 5}},
+			wantAways: []interval{{AwayIdle, 10, 100}},
+		},
+		{
+			name: "idle extends the away span and returning starts a new span",
+			polls: []poll{
+				{at: 0, app: "Code"}, {at: 50, app: "Code"},
+				{at: 100, app: "Code", idle: 70}, {at: 120, app: "Code", idle: 90},
+				{at: 130, app: "Code"},
+			},
+			wantSpans: []interval{{"Code", 0, 30}, {"Code", 130, 130}},
+			wantAways: []interval{{AwayIdle, 30, 120}},
+		},
+		{
+			name:      "power assertions keep tracking while idle",
+			polls:     []poll{{at: 0, app: "Code"}, {at: 50, app: "Code", idle: 70, power: true}},
+			wantSpans: []interval{{"Code", 0, 50}},
+		},
+		{
+			name:      "locked screen is away",
+			polls:     []poll{{at: 0, app: "Code"}, {at: 20, app: "Code"}, {at: 40, locked: true}, {at: 60, locked: true}},
+			wantSpans: []interval{{"Code", 0, 20}},
+			wantAways: []interval{{AwayLocked, 40, 60}},
+		},
+		{
+			name:      "nothing is recorded until the focused window is reported",
+			polls:     []poll{{at: 0, notReported: true}, {at: 5, notReported: true}, {at: 10, app: "Code"}},
+			wantSpans: []interval{{"Code", 10, 10}},
+		},
+		{
+			name: "deleted span isn't given to the previous span",
+			polls: []poll{
+				{at: 0, app: "Code"}, {at: 10, app: "Slack"}, {at: 20, app: "Slack"},
+				{at: 30, app: "Code", edit: func(ctx context.Context, db *store.Queries, start int64) error {
+					return spanedit.Delete(ctx, db, 2)
+				}},
+			},
+			wantSpans: []interval{{"Code", 0, 10}, {"Code", 30, 30}},
+		},
+		{
+			name: "trimmed open span stays trimmed",
+			polls: []poll{
+				{at: 0, app: "Code"}, {at: 10, app: "Code"},
+				{at: 20, app: "Code", edit: func(ctx context.Context, db *store.Queries, start int64) error {
+					_, err := spanedit.Trim(ctx, db, 1, start, start+5)
+					return err
+				}},
+				{at: 30, app: "Code"},
+			},
+			wantSpans: []interval{{"Code", 0, 5}, {"Code", 20, 30}},
+		},
+		{
+			name: "idle doesn't trim an edited span",
+			polls: []poll{
+				{at: 0, app: "Code"}, {at: 50, app: "Code"},
+				{at: 100, app: "Code", idle: 70, edit: func(ctx context.Context, db *store.Queries, start int64) error {
+					_, err := spanedit.Trim(ctx, db, 1, start, start+45)
+					return err
+				}},
+			},
+			wantSpans: []interval{{"Code", 0, 45}},
+			wantAways: []interval{{AwayIdle, 45, 100}},
+		},
+		{
+			name:      "no polls for longer than the stale threshold is asleep",
+			polls:     []poll{{at: 0, app: "Code"}, {at: 20, app: "Code"}, {at: 1000, app: "Code"}},
+			wantSpans: []interval{{"Code", 0, 20}, {"Code", 1000, 1000}},
+			wantAways: []interval{{AwayAsleep, 20, 1000}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, closeDB, err := store.InitDB(filepath.Join(t.TempDir(), "db.sqlite"))
+			if err != nil {
+				t.Fatalf("InitDB() error = %v", err)
+			}
+			defer closeDB()
+
+			windows, idle, power := &FakeWindowSource{}, &FakeIdleSource{}, &FakePowerSource{}
+			tracker := New(db, Sources{Windows: windows, Idle: idle, Power: power}, time.Minute, 5*time.Minute)
+
+			start := time.Unix(1_700_000_000, 0)
+			var now time.Time
+			tracker.Now = func() time.Time { return now }
+
+			for _, p := range tt.polls {
+				now = start.Add(time.Duration(p.at) * time.Second)
+				if p.edit != nil {
+					if err := p.edit(ctx, db, start.Unix()); err != nil {
+						t.Fatalf("edit before %d error = %v", p.at, err)
+					}
+				}
+				windows.SetActive(p.app, "main.go")
+				windows.SetError(nil)
+				if p.notReported {
+					windows.SetError(ErrNotReported)
+				}
+				idle.SetIdle(p.idle)
+				idle.SetLocked(p.locked)
+				power.SetAssertions(nil)
+				if p.power {
+					power.SetAssertions([]PowerAssertionInfo{{ProcessName: "zoom", AssertionType: "NoDisplaySleep"}})
+				}
+				if err := tracker.CollectAndLog(ctx); err != nil {
+					t.Fatalf("CollectAndLog() at %d error = %v", p.at, err)
+				}
+			}
+
+			spans, err := db.SelectSpansBetween(ctx, store.SelectSpansBetweenParams{StartAt: 0, EndAt: 1 << 40})
+			if err != nil {
+				t.Fatalf("SelectSpansBetween() error = %v", err)
+			}
+			var gotSpans []interval
+			for _, span := range spans {
+				gotSpans = append(gotSpans, interval{span.AppName, span.StartAt - start.Unix(), span.EndAt - start.Unix()})
+			}
+			if !slices.Equal(gotSpans, tt.wantSpans) {
+				t.Errorf("spans = %v, want %v", gotSpans, tt.wantSpans)
+			}
+
+			aways, err := db.SelectAwaySpansBetween(ctx, store.SelectAwaySpansBetweenParams{StartAt: 0, EndAt: 1 << 40})
+			if err != nil {
+				t.Fatalf("SelectAwaySpansBetween() error = %v", err)
+			}
+			var gotAways []interval
+			for _, away := range aways {
+				gotAways = append(gotAways, interval{away.Kind, away.StartAt - start.Unix(), away.EndAt - start.Unix()})
+			}
+			if !slices.Equal(gotAways, tt.wantAways) {
+				t.Errorf("away spans = %v, want %v", gotAways, tt.wantAways)
+			}
+		})
+	}
+}
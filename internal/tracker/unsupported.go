@@ -0,0 +1,19 @@
+package tracker
+
+// unsupported* sources are used where a platform has no implementation; they always report ErrUnsupported.
+
+type unsupportedWindows struct{}
+
+func (unsupportedWindows) GetWindows() ([]WindowInfo, error) { return nil, ErrUnsupported }
+
+type unsupportedIdle struct{}
+
+func (unsupportedIdle) GetIdleTime() (float64, error) { return 0, ErrUnsupported }
+
+type unsupportedPower struct{}
+
+func (unsupportedPower) HasActivePowerAssertions() (bool, error) { return false, ErrUnsupported }
+
+func (unsupportedPower) GetPowerAssertions() ([]PowerAssertionInfo, error) {
+	return nil, ErrUnsupported
+}
@@ -12,13 +12,6 @@ import (
 	"unsafe"
 )
 
-type WindowInfo struct {
-	AppName     string
-	RawAppName  string
-	WindowTitle string
-	IsActive    bool
-}
-
 func GetWindows() ([]WindowInfo, error) {
 	// Call the C function to get window list
 	windowList := C.getWindowList()
@@ -40,6 +33,9 @@ func GetWindows() ([]WindowInfo, error) {
 			RawAppName:  C.GoString(cWin.appName), // CoreGraphics gives us the display name
 			WindowTitle: C.GoString(cWin.windowTitle),
 			IsActive:    cWin.isActive == 1,
+			AppID:       C.GoString(cWin.appID),
+			PID:         int32(cWin.pid),
+			Display:     C.GoString(cWin.displayName),
 		})
 	}
 
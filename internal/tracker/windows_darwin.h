@@ -9,6 +9,8 @@ typedef struct {
     char* windowTitle;
     int isActive;
     int pid;
+    char* appID;       // bundle identifier, or the executable path for apps without a bundle
+    char* displayName; // name of the display containing the window's center
 } WindowData;
 
 typedef struct {
@@ -35,6 +35,44 @@ char* getFocusedWindowTitle(pid_t pid) {
     return result;
 }
 
+// Get the bundle identifier of the app owning pid, falling back to its executable path
+static char* copyAppID(pid_t pid) {
+    @autoreleasepool {
+        NSRunningApplication* app = [NSRunningApplication runningApplicationWithProcessIdentifier:pid];
+        NSString* appID = app.bundleIdentifier;
+        if (!appID) {
+            appID = app.executableURL.path;
+        }
+        return strdup(appID ? appID.UTF8String : "");
+    }
+}
+
+// Get the name of the display containing the center of the window's bounds
+static char* copyDisplayName(CFDictionaryRef window) {
+    CFDictionaryRef boundsRef = CFDictionaryGetValue(window, kCGWindowBounds);
+    CGRect bounds;
+    if (!boundsRef || !CGRectMakeWithDictionaryRepresentation(boundsRef, &bounds)) {
+        return strdup("");
+    }
+
+    CGDirectDisplayID displayID = 0;
+    uint32_t displayCount = 0;
+    CGPoint center = CGPointMake(CGRectGetMidX(bounds), CGRectGetMidY(bounds));
+    if (CGGetDisplaysWithPoint(center, 1, &displayID, &displayCount) != kCGErrorSuccess || displayCount == 0) {
+        return strdup("");
+    }
+
+    @autoreleasepool {
+        for (NSScreen* screen in [NSScreen screens]) {
+            NSNumber* screenNumber = screen.deviceDescription[@"NSScreenNumber"];
+            if (screenNumber.unsignedIntValue == displayID) {
+                return strdup(screen.localizedName.UTF8String);
+            }
+        }
+        return strdup([NSString stringWithFormat:@"%u", displayID].UTF8String);
+    }
+}
+
 WindowList getWindowList() {
     WindowList result = {NULL, 0};
 
@@ -117,6 +155,8 @@ WindowList getWindowList() {
         windows[validCount].windowTitle = strdup(windowName);
         windows[validCount].isActive = isActive;
         windows[validCount].pid = pid;
+        windows[validCount].appID = copyAppID(pid);
+        windows[validCount].displayName = copyDisplayName(window);
         validCount++;
     }
 
@@ -132,6 +172,8 @@ void freeWindowList(WindowList list) {
     for (int i = 0; i < list.count; i++) {
         free(list.windows[i].appName);
         free(list.windows[i].windowTitle);
+        free(list.windows[i].appID);
+        free(list.windows[i].displayName);
     }
     free(list.windows);
 }
@@ -0,0 +1,127 @@
+//go:build linux
+
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// The D-Bus interface published by the daemon. The GNOME Shell extension and KWin script in contrib/ call Update
+// whenever the focused window or its title changes.
+const (
+	DBusWindowsName  = "com.fritzkeyzer.MacTimeTracker"
+	DBusWindowsPath  = dbus.ObjectPath("/com/fritzkeyzer/MacTimeTracker/Window")
+	DBusWindowsIface = "com.fritzkeyzer.MacTimeTracker.Window"
+)
+
+// DBusWindows is a WindowSource for GNOME and KDE Wayland sessions, where only the compositor knows the focused
+// window. A companion shell extension or KWin script reports focus changes to it over the session bus.
+type DBusWindows struct {
+	mu       sync.Mutex
+	reported bool // whether the companion called Update since the daemon started
+	active   *WindowInfo
+	onChange func(WindowInfo)
+}
+
+// NewDBusWindows exports the window interface on conn (usually the session bus) and claims DBusWindowsName.
+func NewDBusWindows(conn *dbus.Conn) (*DBusWindows, error) {
+	d := &DBusWindows{}
+	handler := dbusWindowsHandler{d}
+
+	if err := conn.Export(handler, DBusWindowsPath, DBusWindowsIface); err != nil {
+		return nil, fmt.Errorf("export window interface: %w", err)
+	}
+
+	node := &introspect.Node{
+		Name: string(DBusWindowsPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			{
+				Name:    DBusWindowsIface,
+				Methods: introspect.Methods(handler),
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), DBusWindowsPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, fmt.Errorf("export introspection: %w", err)
+	}
+
+	reply, err := conn.RequestName(DBusWindowsName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, fmt.Errorf("request name %s: %w", DBusWindowsName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, fmt.Errorf("name %s is already taken, is another daemon running?", DBusWindowsName)
+	}
+
+	return d, nil
+}
+
+// GetWindows returns the last window reported by the companion, nothing if no window is focused, or ErrNotReported
+// if the companion hasn't reported yet.
+func (d *DBusWindows) GetWindows() ([]WindowInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.reported {
+		return nil, ErrNotReported
+	}
+	if d.active == nil {
+		return []WindowInfo{}, nil
+	}
+	return []WindowInfo{*d.active}, nil
+}
+
+// WatchFocus calls fn whenever the companion reports a newly focused window. It blocks until ctx is cancelled.
+func (d *DBusWindows) WatchFocus(ctx context.Context, fn func(WindowInfo)) error {
+	d.mu.Lock()
+	d.onChange = fn
+	d.mu.Unlock()
+
+	<-ctx.Done()
+
+	d.mu.Lock()
+	d.onChange = nil
+	d.mu.Unlock()
+	return ctx.Err()
+}
+
+func (d *DBusWindows) setActive(info *WindowInfo) {
+	d.mu.Lock()
+	d.reported = true
+	d.active = info
+	onChange := d.onChange
+	d.mu.Unlock()
+
+	if info != nil && onChange != nil {
+		onChange(*info)
+	}
+}
+
+// dbusWindowsHandler holds the exported D-Bus methods, keeping them off the DBusWindows API.
+type dbusWindowsHandler struct {
+	d *DBusWindows
+}
+
+// Update records the focused window. An empty title means nothing is focused (e.g. the desktop or the overview).
+func (h dbusWindowsHandler) Update(appName, appID, title string, pid uint32) *dbus.Error {
+	if title == "" {
+		h.d.setActive(nil)
+		return nil
+	}
+
+	h.d.setActive(&WindowInfo{
+		AppName:     appName,
+		RawAppName:  appID,
+		WindowTitle: title,
+		IsActive:    true,
+		AppID:       appIDForPID(int32(pid), appID),
+		PID:         int32(pid),
+	})
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build linux
+
+package tracker
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// privateBus starts a dbus-daemon listening in a temporary directory and returns its address. The test is skipped
+// when dbus-daemon isn't installed.
+func privateBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(config, []byte(`<busconfig>
+  <type>session</type>
+  <listen>unix:path=`+filepath.Join(dir, "bus.sock")+`</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default"><allow send_destination="*" eavesdrop="true"/><allow eavesdrop="true"/><allow own="*"/></policy>
+</busconfig>`), 0o600); err != nil {
+		t.Fatalf("write bus config: %v", err)
+	}
+
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read bus address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// connectBus opens a connection to the bus at address, closed when the test ends.
+func connectBus(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("connect to bus: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// update is a call of the companion to the Update method.
+type update struct {
+	appName, appID, title string
+	pid                   uint32
+}
+
+func TestDBusWindows(t *testing.T) {
+	address := privateBus(t)
+
+	firefox := WindowInfo{AppName: "Firefox", RawAppName: "firefox", WindowTitle: "Inbox - Mozilla Firefox", AppID: "firefox", IsActive: true}
+	terminal := WindowInfo{AppName: "Terminal", RawAppName: "org.gnome.Terminal", WindowTitle: "~/src", AppID: "org.gnome.Terminal", IsActive: true}
+
+	tests := []struct {
+		name        string
+		updates     []update
+		want        []WindowInfo
+		wantErr     error
+		wantWatched []WindowInfo
+	}{
+		{
+			name:    "nothing reported yet",
+			wantErr: ErrNotReported,
+		},
+		{
+			name:        "focused window",
+			updates:     []update{{"Firefox", "firefox", "Inbox - Mozilla Firefox", 0}},
+			want:        []WindowInfo{firefox},
+			wantWatched: []WindowInfo{firefox},
+		},
+		{
+			name: "latest window wins",
+			updates: []update{
+				{"Firefox", "firefox", "Inbox - Mozilla Firefox", 0},
+				{"Terminal", "org.gnome.Terminal", "~/src", 0},
+			},
+			want:        []WindowInfo{terminal},
+			wantWatched: []WindowInfo{firefox, terminal},
+		},
+		{
+			name: "nothing focused",
+			updates: []update{
+				{"Firefox", "firefox", "Inbox - Mozilla Firefox", 0},
+				{"", "", "", 0},
+			},
+			want:        []WindowInfo{},
+			wantWatched: []WindowInfo{firefox},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonConn := connectBus(t, address)
+			d, err := NewDBusWindows(daemonConn)
+			if err != nil {
+				t.Fatalf("NewDBusWindows() error = %v", err)
+			}
+			defer daemonConn.ReleaseName(DBusWindowsName)
+
+			// register the callback as WatchFocus does, but before the first update
+			watched := make(chan WindowInfo, len(tt.updates))
+			d.mu.Lock()
+			d.onChange = func(info WindowInfo) { watched <- info }
+			d.mu.Unlock()
+
+			companion := connectBus(t, address).Object(DBusWindowsName, DBusWindowsPath)
+			for _, u := range tt.updates {
+				if err := companion.Call(DBusWindowsIface+".Update", 0, u.appName, u.appID, u.title, u.pid).Err; err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+			}
+
+			got, err := d.GetWindows()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetWindows() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetWindows() = %+v, want %+v", got, tt.want)
+			}
+
+			var gotWatched []WindowInfo
+			for range tt.wantWatched {
+				select {
+				case info := <-watched:
+					gotWatched = append(gotWatched, info)
+				case <-time.After(time.Second):
+				}
+			}
+			if !slices.Equal(gotWatched, tt.wantWatched) {
+				t.Errorf("WatchFocus() reported %+v, want %+v", gotWatched, tt.wantWatched)
+			}
+		})
+	}
+}
+
+func TestDBusWindowsNameTaken(t *testing.T) {
+	address := privateBus(t)
+
+	if _, err := NewDBusWindows(connectBus(t, address)); err != nil {
+		t.Fatalf("NewDBusWindows() error = %v", err)
+	}
+	if _, err := NewDBusWindows(connectBus(t, address)); err == nil {
+		t.Errorf("second NewDBusWindows() error = nil, want the name to be taken")
+	}
+}
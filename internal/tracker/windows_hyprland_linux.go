@@ -0,0 +1,194 @@
+//go:build linux
+
+package tracker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const hyprlandIPCTimeout = 2 * time.Second
+
+// HyprlandWindows reads windows from the Hyprland request socket and focus changes from its event socket.
+type HyprlandWindows struct {
+	requestSocket string
+	eventSocket   string
+}
+
+// NewHyprlandWindows locates the sockets of the Hyprland instance identified by signature,
+// usually $HYPRLAND_INSTANCE_SIGNATURE.
+func NewHyprlandWindows(signature string) *HyprlandWindows {
+	// Hyprland >= 0.40 keeps its sockets in $XDG_RUNTIME_DIR, older versions in /tmp
+	dir := filepath.Join("/tmp/hypr", signature)
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		if _, err := os.Stat(filepath.Join(runtimeDir, "hypr", signature)); err == nil {
+			dir = filepath.Join(runtimeDir, "hypr", signature)
+		}
+	}
+	return NewHyprlandWindowsAt(filepath.Join(dir, ".socket.sock"), filepath.Join(dir, ".socket2.sock"))
+}
+
+// NewHyprlandWindowsAt uses explicit request and event socket paths.
+func NewHyprlandWindowsAt(requestSocket, eventSocket string) *HyprlandWindows {
+	return &HyprlandWindows{
+		requestSocket: requestSocket,
+		eventSocket:   eventSocket,
+	}
+}
+
+type hyprlandClient struct {
+	Address        string `json:"address"`
+	Mapped         bool   `json:"mapped"`
+	Hidden         bool   `json:"hidden"`
+	Class          string `json:"class"`
+	InitialClass   string `json:"initialClass"`
+	Title          string `json:"title"`
+	PID            int32  `json:"pid"`
+	Monitor        int    `json:"monitor"`
+	FocusHistoryID int    `json:"focusHistoryID"`
+}
+
+type hyprlandMonitor struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (c hyprlandClient) windowInfo() WindowInfo {
+	return WindowInfo{
+		AppName:     c.Class,
+		RawAppName:  c.InitialClass,
+		WindowTitle: c.Title,
+		AppID:       appIDForPID(c.PID, c.InitialClass),
+		PID:         c.PID,
+	}
+}
+
+// GetWindows returns the mapped windows ordered by focus history, marking the active window.
+func (h *HyprlandWindows) GetWindows() ([]WindowInfo, error) {
+	var active hyprlandClient
+	if err := h.request("j/activewindow", &active); err != nil {
+		return nil, fmt.Errorf("get active window: %w", err)
+	}
+
+	var clients []hyprlandClient
+	if err := h.request("j/clients", &clients); err != nil {
+		return nil, fmt.Errorf("get clients: %w", err)
+	}
+
+	var monitors []hyprlandMonitor
+	if err := h.request("j/monitors", &monitors); err != nil {
+		return nil, fmt.Errorf("get monitors: %w", err)
+	}
+	monitorNames := make(map[int]string, len(monitors))
+	for _, m := range monitors {
+		monitorNames[m.ID] = m.Name
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].FocusHistoryID < clients[j].FocusHistoryID
+	})
+
+	windows := make([]WindowInfo, 0, len(clients))
+	for _, c := range clients {
+		if !c.Mapped || c.Hidden || c.Title == "" {
+			continue
+		}
+		info := c.windowInfo()
+		info.IsActive = active.Address != "" && c.Address == active.Address
+		info.Display = monitorNames[c.Monitor]
+		windows = append(windows, info)
+	}
+
+	return windows, nil
+}
+
+// request sends a single hyprctl style command and decodes the JSON reply into out.
+func (h *HyprlandWindows) request(command string, out any) error {
+	conn, err := net.DialTimeout("unix", h.requestSocket, hyprlandIPCTimeout)
+	if err != nil {
+		return fmt.Errorf("dial hyprland socket: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(hyprlandIPCTimeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte(command)); err != nil {
+		return err
+	}
+
+	// Hyprland closes the connection after writing the reply
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return err
+	}
+
+	// activewindow replies with an empty object when nothing is focused
+	if err := json.Unmarshal(reply, out); err != nil {
+		return fmt.Errorf("decode %q reply: %w", command, err)
+	}
+	return nil
+}
+
+// WatchFocus reads the event socket and calls fn whenever the active window or its title changes.
+// It blocks until ctx is cancelled or the connection fails.
+func (h *HyprlandWindows) WatchFocus(ctx context.Context, fn func(WindowInfo)) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", h.eventSocket)
+	if err != nil {
+		return fmt.Errorf("dial hyprland event socket: %w", err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		event, data, ok := strings.Cut(scanner.Text(), ">>")
+		if !ok {
+			continue
+		}
+
+		switch event {
+		case "activewindow":
+			// activewindow>>WINDOWCLASS,WINDOWTITLE (the title itself may contain commas)
+			class, title, _ := strings.Cut(data, ",")
+			if class == "" && title == "" {
+				continue // focus moved to an empty workspace
+			}
+			fn(WindowInfo{
+				AppName:     class,
+				RawAppName:  class,
+				WindowTitle: title,
+				IsActive:    true,
+			})
+		case "windowtitle", "windowtitlev2":
+			// the event only carries the window address, so look the active window up again
+			var active hyprlandClient
+			if err := h.request("j/activewindow", &active); err != nil || active.Address == "" {
+				continue
+			}
+			info := active.windowInfo()
+			info.IsActive = true
+			fn(info)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read hyprland event: %w", err)
+	}
+	return io.ErrUnexpectedEOF
+}
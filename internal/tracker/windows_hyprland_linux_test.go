@@ -0,0 +1,141 @@
+//go:build linux
+
+package tracker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeHyprland answers requests on its request socket from replies, keyed by command, and writes events to each
+// connection of its event socket before closing it.
+func fakeHyprland(t *testing.T, replies map[string]string, events ...string) *HyprlandWindows {
+	requestSocket := fakeSocket(t, ".socket.sock", func(conn net.Conn) {
+		buf := make([]byte, 1024)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(conn, replies[string(buf[:n])])
+	})
+	eventSocket := fakeSocket(t, ".socket2.sock", func(conn net.Conn) {
+		for _, event := range events {
+			_, _ = io.WriteString(conn, event+"\n")
+		}
+	})
+	return NewHyprlandWindowsAt(requestSocket, eventSocket)
+}
+
+const hyprlandMonitors = `[{"id": 0, "name": "eDP-1"}, {"id": 1, "name": "DP-2"}]`
+
+func TestHyprlandGetWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies map[string]string
+		want    []WindowInfo
+	}{
+		{
+			name: "mapped windows by focus history",
+			replies: map[string]string{
+				"j/activewindow": `{"address": "0x2", "class": "foot", "initialClass": "foot", "title": "~/src"}`,
+				"j/clients": `[
+					{"address": "0x1", "mapped": true, "class": "code-url-handler", "initialClass": "code-url-handler", "title": "main.go - mtt - Visual Studio Code", "monitor": 1, "focusHistoryID": 1},
+					{"address": "0x2", "mapped": true, "class": "foot", "initialClass": "foot", "title": "~/src", "monitor": 0, "focusHistoryID": 0},
+					{"address": "0x3", "mapped": true, "hidden": true, "class": "Slack", "initialClass": "Slack", "title": "Slack", "focusHistoryID": 2},
+					{"address": "0x4", "mapped": false, "class": "firefox", "initialClass": "firefox", "title": "Mozilla Firefox", "focusHistoryID": 3},
+					{"address": "0x5", "mapped": true, "class": "wofi", "initialClass": "wofi", "title": "", "focusHistoryID": 4}
+				]`,
+				"j/monitors": hyprlandMonitors,
+			},
+			want: []WindowInfo{
+				{AppName: "foot", RawAppName: "foot", WindowTitle: "~/src", AppID: "foot", IsActive: true, Display: "eDP-1"},
+				{AppName: "code-url-handler", RawAppName: "code-url-handler", WindowTitle: "main.go - mtt - Visual Studio Code", AppID: "code-url-handler", Display: "DP-2"},
+			},
+		},
+		{
+			name: "nothing focused",
+			replies: map[string]string{
+				"j/activewindow": `{}`,
+				"j/clients":      `[{"address": "0x1", "mapped": true, "class": "foot", "initialClass": "foot", "title": "~", "monitor": 0}]`,
+				"j/monitors":     hyprlandMonitors,
+			},
+			want: []WindowInfo{
+				{AppName: "foot", RawAppName: "foot", WindowTitle: "~", AppID: "foot", Display: "eDP-1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fakeHyprland(t, tt.replies).GetWindows()
+			if err != nil {
+				t.Fatalf("GetWindows() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetWindows() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHyprlandGetWindowsBadReply(t *testing.T) {
+	_, err := fakeHyprland(t, map[string]string{"j/activewindow": "unknown request"}).GetWindows()
+	if err == nil || !strings.Contains(err.Error(), "get active window") {
+		t.Errorf("GetWindows() error = %v, want a get active window error", err)
+	}
+}
+
+func TestHyprlandWatchFocus(t *testing.T) {
+	activeWindow := `{"address": "0x2", "class": "firefox", "initialClass": "firefox", "title": "Inbox - Mozilla Firefox"}`
+
+	tests := []struct {
+		name   string
+		events []string
+		want   []WindowInfo
+	}{
+		{
+			name: "activewindow events",
+			events: []string{
+				"activewindow>>foot,~/src",
+				"activewindow>>Code,a, b, c - Visual Studio Code",
+			},
+			want: []WindowInfo{
+				{AppName: "foot", RawAppName: "foot", WindowTitle: "~/src", IsActive: true},
+				{AppName: "Code", RawAppName: "Code", WindowTitle: "a, b, c - Visual Studio Code", IsActive: true},
+			},
+		},
+		{
+			name:   "title changes look the active window up",
+			events: []string{"windowtitle>>2", "windowtitlev2>>2,Inbox - Mozilla Firefox"},
+			want: []WindowInfo{
+				{AppName: "firefox", RawAppName: "firefox", WindowTitle: "Inbox - Mozilla Firefox", AppID: "firefox", IsActive: true},
+				{AppName: "firefox", RawAppName: "firefox", WindowTitle: "Inbox - Mozilla Firefox", AppID: "firefox", IsActive: true},
+			},
+		},
+		{
+			name:   "empty workspaces and other events are ignored",
+			events: []string{"activewindow>>,", "workspace>>2", "activewindowv2>>2", "garbage"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := fakeHyprland(t, map[string]string{"j/activewindow": activeWindow}, tt.events...)
+
+			var got []WindowInfo
+			err := h.WatchFocus(context.Background(), func(info WindowInfo) {
+				got = append(got, info)
+			})
+			// the fake closes the event socket after the events
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("WatchFocus() error = %v, want %v", err, io.ErrUnexpectedEOF)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("WatchFocus() reported\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+//go:build linux
+
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// sway IPC message types, see sway-ipc(7)
+const (
+	swayMsgSubscribe uint32 = 2
+	swayMsgGetTree   uint32 = 4
+	swayEventWindow  uint32 = 0x80000003
+)
+
+const swayIPCTimeout = 2 * time.Second
+
+var swayMagic = []byte("i3-ipc")
+
+// SwayWindows reads windows from the sway (or i3) IPC socket.
+type SwayWindows struct {
+	socketPath string
+}
+
+// NewSwayWindows talks to the IPC socket at socketPath, usually $SWAYSOCK.
+func NewSwayWindows(socketPath string) *SwayWindows {
+	return &SwayWindows{socketPath: socketPath}
+}
+
+// swayNode is the subset of a get_tree node we care about
+type swayNode struct {
+	Type             string `json:"type"`
+	Name             string `json:"name"`
+	Focused          bool   `json:"focused"`
+	AppID            string `json:"app_id"`
+	PID              int32  `json:"pid"`
+	WindowProperties *struct {
+		Class    string `json:"class"`
+		Instance string `json:"instance"`
+	} `json:"window_properties"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+func (n swayNode) isWindow() bool {
+	return (n.Type == "con" || n.Type == "floating_con") && (n.AppID != "" || n.WindowProperties != nil)
+}
+
+func (n swayNode) windowInfo() WindowInfo {
+	info := WindowInfo{
+		AppName:     n.AppID, // native wayland clients
+		RawAppName:  n.AppID,
+		WindowTitle: n.Name,
+		IsActive:    n.Focused,
+		PID:         n.PID,
+	}
+	if n.WindowProperties != nil {
+		// Xwayland clients
+		info.AppName = n.WindowProperties.Class
+		info.RawAppName = n.WindowProperties.Instance
+	}
+	info.AppID = appIDForPID(n.PID, info.RawAppName)
+	return info
+}
+
+type swayWindowEvent struct {
+	Change    string   `json:"change"`
+	Container swayNode `json:"container"`
+}
+
+// GetWindows returns every window in the tree, with the focused window first.
+func (s *SwayWindows) GetWindows() ([]WindowInfo, error) {
+	conn, err := net.DialTimeout("unix", s.socketPath, swayIPCTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("dial sway ipc: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(swayIPCTimeout)); err != nil {
+		return nil, err
+	}
+
+	if err := swayWrite(conn, swayMsgGetTree, nil); err != nil {
+		return nil, fmt.Errorf("send get_tree: %w", err)
+	}
+	_, payload, err := swayRead(conn)
+	if err != nil {
+		return nil, fmt.Errorf("read get_tree: %w", err)
+	}
+
+	var root swayNode
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return nil, fmt.Errorf("decode get_tree: %w", err)
+	}
+
+	var windows []WindowInfo
+	var walk func(n swayNode, output string)
+	walk = func(n swayNode, output string) {
+		if n.Type == "output" {
+			output = n.Name
+		}
+		if n.isWindow() && n.Name != "" {
+			info := n.windowInfo()
+			info.Display = output
+			if n.Focused {
+				windows = append([]WindowInfo{info}, windows...)
+			} else {
+				windows = append(windows, info)
+			}
+		}
+		for _, child := range n.Nodes {
+			walk(child, output)
+		}
+		for _, child := range n.FloatingNodes {
+			walk(child, output)
+		}
+	}
+	walk(root, "")
+
+	return windows, nil
+}
+
+// WatchFocus subscribes to window events and calls fn whenever the focused window or its title changes.
+// It blocks until ctx is cancelled or the connection fails.
+func (s *SwayWindows) WatchFocus(ctx context.Context, fn func(WindowInfo)) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("dial sway ipc: %w", err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := swayWrite(conn, swayMsgSubscribe, []byte(`["window"]`)); err != nil {
+		return fmt.Errorf("send subscribe: %w", err)
+	}
+	_, payload, err := swayRead(conn)
+	if err != nil {
+		return fmt.Errorf("read subscribe reply: %w", err)
+	}
+	var reply struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(payload, &reply); err != nil || !reply.Success {
+		return fmt.Errorf("subscribe to window events failed: %s", payload)
+	}
+
+	for {
+		msgType, payload, err := swayRead(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("read window event: %w", err)
+		}
+		if msgType != swayEventWindow {
+			continue
+		}
+
+		var event swayWindowEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("decode window event: %w", err)
+		}
+		if event.Change != "focus" && event.Change != "title" {
+			continue
+		}
+		if !event.Container.Focused {
+			continue
+		}
+
+		info := event.Container.windowInfo()
+		info.IsActive = true
+		fn(info)
+	}
+}
+
+func swayWrite(w io.Writer, msgType uint32, payload []byte) error {
+	var buf bytes.Buffer
+	buf.Write(swayMagic)
+	_ = binary.Write(&buf, binary.NativeEndian, uint32(len(payload)))
+	_ = binary.Write(&buf, binary.NativeEndian, msgType)
+	buf.Write(payload)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func swayRead(r io.Reader) (uint32, []byte, error) {
+	header := make([]byte, len(swayMagic)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	if !bytes.Equal(header[:len(swayMagic)], swayMagic) {
+		return 0, nil, errors.New("invalid sway ipc magic")
+	}
+
+	length := binary.NativeEndian.Uint32(header[len(swayMagic):])
+	msgType := binary.NativeEndian.Uint32(header[len(swayMagic)+4:])
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return msgType, payload, nil
+}
@@ -0,0 +1,164 @@
+//go:build linux
+
+package tracker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fakeSocket listens on a unix socket in a temporary directory and serves each connection with handle.
+func fakeSocket(t *testing.T, name string, handle func(conn net.Conn)) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return path
+}
+
+// fakeSway answers get_tree with tree, and subscribe with a success reply followed by events, after which it closes
+// the connection.
+func fakeSway(t *testing.T, tree string, events ...string) string {
+	return fakeSocket(t, "sway.sock", func(conn net.Conn) {
+		msgType, _, err := swayRead(conn)
+		if err != nil {
+			return
+		}
+		switch msgType {
+		case swayMsgGetTree:
+			_ = swayWrite(conn, swayMsgGetTree, []byte(tree))
+		case swayMsgSubscribe:
+			_ = swayWrite(conn, swayMsgSubscribe, []byte(`{"success": true}`))
+			for _, event := range events {
+				_ = swayWrite(conn, swayEventWindow, []byte(event))
+			}
+		}
+	})
+}
+
+func TestSwayGetWindows(t *testing.T) {
+	tests := []struct {
+		name string
+		tree string
+		want []WindowInfo
+	}{
+		{
+			name: "wayland and xwayland windows, focused first",
+			tree: `{"type": "root", "nodes": [
+				{"type": "output", "name": "__i3", "nodes": []},
+				{"type": "output", "name": "DP-1", "nodes": [
+					{"type": "workspace", "name": "1", "nodes": [
+						{"type": "con", "name": "main.go - mtt - Visual Studio Code", "window_properties": {"class": "Code", "instance": "code"}},
+						{"type": "con", "name": null, "nodes": [
+							{"type": "con", "name": "~/src", "app_id": "foot", "focused": true}
+						]}
+					]}
+				]},
+				{"type": "output", "name": "HDMI-A-1", "nodes": [
+					{"type": "workspace", "name": "2", "nodes": [], "floating_nodes": [
+						{"type": "floating_con", "name": "Mozilla Firefox", "app_id": "firefox"}
+					]}
+				]}
+			]}`,
+			want: []WindowInfo{
+				{AppName: "foot", RawAppName: "foot", WindowTitle: "~/src", AppID: "foot", IsActive: true, Display: "DP-1"},
+				{AppName: "Code", RawAppName: "code", WindowTitle: "main.go - mtt - Visual Studio Code", AppID: "code", Display: "DP-1"},
+				{AppName: "firefox", RawAppName: "firefox", WindowTitle: "Mozilla Firefox", AppID: "firefox", Display: "HDMI-A-1"},
+			},
+		},
+		{
+			name: "windows without a title are skipped",
+			tree: `{"type": "root", "nodes": [
+				{"type": "output", "name": "eDP-1", "nodes": [
+					{"type": "workspace", "name": "1", "nodes": [
+						{"type": "con", "name": "", "app_id": "wofi", "focused": true},
+						{"type": "con", "name": "Slack", "app_id": "Slack"}
+					]}
+				]}
+			]}`,
+			want: []WindowInfo{
+				{AppName: "Slack", RawAppName: "Slack", WindowTitle: "Slack", AppID: "Slack", Display: "eDP-1"},
+			},
+		},
+		{
+			name: "empty workspace",
+			tree: `{"type": "root", "nodes": [{"type": "output", "name": "eDP-1", "nodes": [{"type": "workspace", "name": "1"}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSwayWindows(fakeSway(t, tt.tree)).GetWindows()
+			if err != nil {
+				t.Fatalf("GetWindows() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetWindows() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwayWatchFocus(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   []WindowInfo
+	}{
+		{
+			name: "focus and title changes of the focused window",
+			events: []string{
+				`{"change": "focus", "container": {"type": "con", "name": "~/src", "app_id": "foot", "focused": true}}`,
+				`{"change": "title", "container": {"type": "con", "name": "~/src/mtt", "app_id": "foot", "focused": true}}`,
+				`{"change": "focus", "container": {"type": "con", "name": "Inbox", "window_properties": {"class": "Thunderbird", "instance": "Mail"}, "focused": true}}`,
+			},
+			want: []WindowInfo{
+				{AppName: "foot", RawAppName: "foot", WindowTitle: "~/src", AppID: "foot", IsActive: true},
+				{AppName: "foot", RawAppName: "foot", WindowTitle: "~/src/mtt", AppID: "foot", IsActive: true},
+				{AppName: "Thunderbird", RawAppName: "Mail", WindowTitle: "Inbox", AppID: "Mail", IsActive: true},
+			},
+		},
+		{
+			name: "other changes and unfocused windows are ignored",
+			events: []string{
+				`{"change": "new", "container": {"type": "con", "name": "~", "app_id": "foot", "focused": true}}`,
+				`{"change": "title", "container": {"type": "con", "name": "Slack | general", "app_id": "Slack", "focused": false}}`,
+				`{"change": "close", "container": {"type": "con", "name": "~", "app_id": "foot"}}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []WindowInfo
+			err := NewSwayWindows(fakeSway(t, "", tt.events...)).WatchFocus(context.Background(), func(info WindowInfo) {
+				got = append(got, info)
+			})
+			// the fake closes the connection after the events
+			if err == nil || errors.Is(err, context.Canceled) {
+				t.Fatalf("WatchFocus() error = %v, want a read error", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("WatchFocus() reported\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+//go:build linux
+
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/xproto"
+)
+
+// X11Windows lists windows using the EWMH properties maintained by the X11 window manager.
+// The connection is opened lazily and re-established after X server errors.
+type X11Windows struct {
+	display string
+
+	mu    sync.Mutex
+	conn  *xgb.Conn
+	root  xproto.Window
+	atoms map[string]xproto.Atom
+	randr bool // whether the RandR extension is available to map windows to monitors
+}
+
+// NewX11Windows connects to the X server at display (e.g. ":0"), or $DISPLAY when empty.
+func NewX11Windows(display string) (*X11Windows, error) {
+	x := &X11Windows{display: display}
+	if err := x.connect(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *X11Windows) connect() error {
+	conn, err := xgb.NewConnDisplay(x.display)
+	if err != nil {
+		return fmt.Errorf("connect to X server: %w", err)
+	}
+
+	atoms, err := internAtoms(conn,
+		"_NET_ACTIVE_WINDOW", "_NET_CLIENT_LIST_STACKING", "_NET_WM_NAME", "_NET_WM_PID", "UTF8_STRING",
+	)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	x.conn = conn
+	x.root = xproto.Setup(conn).DefaultScreen(conn).Root
+	x.atoms = atoms
+	x.randr = randr.Init(conn) == nil
+	return nil
+}
+
+func internAtoms(conn *xgb.Conn, names ...string) (map[string]xproto.Atom, error) {
+	atoms := make(map[string]xproto.Atom, len(names))
+	for _, name := range names {
+		reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+		if err != nil {
+			return nil, fmt.Errorf("intern atom %s: %w", name, err)
+		}
+		atoms[name] = reply.Atom
+	}
+	return atoms, nil
+}
+
+// Close closes the X server connection.
+func (x *X11Windows) Close() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.conn != nil {
+		x.conn.Close()
+		x.conn = nil
+	}
+}
+
+// GetWindows returns the managed windows front to back, marking the window in _NET_ACTIVE_WINDOW as active.
+func (x *X11Windows) GetWindows() ([]WindowInfo, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.conn == nil {
+		if err := x.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	windows, err := x.getWindows()
+	if err != nil {
+		// drop the connection so the next poll reconnects (e.g. after the X server restarted)
+		x.conn.Close()
+		x.conn = nil
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (x *X11Windows) getWindows() ([]WindowInfo, error) {
+	active, err := x.activeWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	// _NET_CLIENT_LIST_STACKING is ordered bottom to top
+	stacking, err := x.windowListProperty(x.root, x.atoms["_NET_CLIENT_LIST_STACKING"])
+	if err != nil {
+		return nil, fmt.Errorf("get client list: %w", err)
+	}
+
+	order := make([]xproto.Window, 0, len(stacking)+1)
+	if active != 0 {
+		order = append(order, active)
+	}
+	for i := len(stacking) - 1; i >= 0; i-- {
+		if stacking[i] != active {
+			order = append(order, stacking[i])
+		}
+	}
+
+	windows := make([]WindowInfo, 0, len(order))
+	for _, w := range order {
+		info, err := x.windowInfo(w)
+		if err != nil {
+			// windows can disappear between listing and querying them
+			continue
+		}
+
+		// Skip windows without names (usually background windows)
+		if info.WindowTitle == "" {
+			continue
+		}
+
+		info.IsActive = w == active
+		if info.IsActive {
+			// only the focused window is recorded, so skip the extra round trips for the others
+			info.Display = x.monitorName(w)
+		}
+		windows = append(windows, info)
+	}
+
+	return windows, nil
+}
+
+func (x *X11Windows) activeWindow() (xproto.Window, error) {
+	return getActiveWindow(x.conn, x.root, x.atoms["_NET_ACTIVE_WINDOW"])
+}
+
+func getActiveWindow(conn *xgb.Conn, root xproto.Window, activeAtom xproto.Atom) (xproto.Window, error) {
+	list, err := getWindowListProperty(conn, root, activeAtom)
+	if err != nil {
+		return 0, fmt.Errorf("get active window: %w", err)
+	}
+	if len(list) == 0 {
+		return 0, nil
+	}
+	return list[0], nil
+}
+
+func (x *X11Windows) windowInfo(w xproto.Window) (WindowInfo, error) {
+	title, err := x.stringProperty(w, x.atoms["_NET_WM_NAME"], x.atoms["UTF8_STRING"])
+	if err != nil {
+		return WindowInfo{}, err
+	}
+	if title == "" {
+		// fall back to the legacy ICCCM name for clients that don't set the EWMH one
+		title, err = x.stringProperty(w, xproto.AtomWmName, xproto.GetPropertyTypeAny)
+		if err != nil {
+			return WindowInfo{}, err
+		}
+	}
+
+	instance, class, err := x.wmClass(w)
+	if err != nil {
+		return WindowInfo{}, err
+	}
+	appName := class
+	if appName == "" {
+		appName = instance
+	}
+
+	pid := x.wmPID(w)
+
+	return WindowInfo{
+		AppName:     appName,
+		RawAppName:  instance, // WM_CLASS instance name, e.g. "code" for class "Code"
+		WindowTitle: title,
+		AppID:       appIDForPID(pid, instance),
+		PID:         pid,
+	}, nil
+}
+
+// wmPID returns the _NET_WM_PID of w, or 0 when the client doesn't set it.
+func (x *X11Windows) wmPID(w xproto.Window) int32 {
+	reply, err := xproto.GetProperty(x.conn, false, w, x.atoms["_NET_WM_PID"], xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil || reply.Format != 32 || len(reply.Value) < 4 {
+		return 0
+	}
+	return int32(xgb.Get32(reply.Value))
+}
+
+// monitorName returns the name of the RandR monitor containing the center of w (e.g. "DP-1").
+func (x *X11Windows) monitorName(w xproto.Window) string {
+	if !x.randr {
+		return ""
+	}
+
+	geom, err := xproto.GetGeometry(x.conn, xproto.Drawable(w)).Reply()
+	if err != nil {
+		return ""
+	}
+	pos, err := xproto.TranslateCoordinates(x.conn, w, x.root, 0, 0).Reply()
+	if err != nil {
+		return ""
+	}
+	cx := int(pos.DstX) + int(geom.Width)/2
+	cy := int(pos.DstY) + int(geom.Height)/2
+
+	monitors, err := randr.GetMonitors(x.conn, x.root, true).Reply()
+	if err != nil {
+		return ""
+	}
+	for _, m := range monitors.Monitors {
+		if cx < int(m.X) || cy < int(m.Y) || cx >= int(m.X)+int(m.Width) || cy >= int(m.Y)+int(m.Height) {
+			continue
+		}
+		name, err := xproto.GetAtomName(x.conn, m.Name).Reply()
+		if err != nil {
+			return ""
+		}
+		return name.Name
+	}
+	return ""
+}
+
+// wmClass returns the instance and class parts of WM_CLASS, which is stored as "instance\x00class\x00".
+func (x *X11Windows) wmClass(w xproto.Window) (string, string, error) {
+	raw, err := x.stringProperty(w, xproto.AtomWmClass, xproto.AtomString)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.Split(strings.TrimRight(raw, "\x00"), "\x00")
+	switch len(parts) {
+	case 0:
+		return "", "", nil
+	case 1:
+		return parts[0], parts[0], nil
+	default:
+		return parts[0], parts[1], nil
+	}
+}
+
+func (x *X11Windows) stringProperty(w xproto.Window, property, typ xproto.Atom) (string, error) {
+	reply, err := xproto.GetProperty(x.conn, false, w, property, typ, 0, 1024).Reply()
+	if err != nil {
+		return "", err
+	}
+	return string(reply.Value[:reply.ValueLen]), nil
+}
+
+func (x *X11Windows) windowListProperty(w xproto.Window, property xproto.Atom) ([]xproto.Window, error) {
+	return getWindowListProperty(x.conn, w, property)
+}
+
+func getWindowListProperty(conn *xgb.Conn, w xproto.Window, property xproto.Atom) ([]xproto.Window, error) {
+	reply, err := xproto.GetProperty(conn, false, w, property, xproto.AtomWindow, 0, 1<<16).Reply()
+	if err != nil {
+		return nil, err
+	}
+	if reply.Format != 32 {
+		return nil, nil
+	}
+
+	windows := make([]xproto.Window, 0, reply.ValueLen)
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		windows = append(windows, xproto.Window(xgb.Get32(reply.Value[i:])))
+	}
+	return windows, nil
+}
+
+// WatchFocus listens for PropertyNotify events on a dedicated connection and calls fn whenever _NET_ACTIVE_WINDOW
+// changes or the active window is renamed. It blocks until ctx is cancelled or the connection fails.
+func (x *X11Windows) WatchFocus(ctx context.Context, fn func(WindowInfo)) error {
+	conn, err := xgb.NewConnDisplay(x.display)
+	if err != nil {
+		return fmt.Errorf("connect to X server: %w", err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, conn.Close)
+	defer stop()
+
+	atoms, err := internAtoms(conn, "_NET_ACTIVE_WINDOW", "_NET_WM_NAME")
+	if err != nil {
+		return err
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	selectProperties := func(w xproto.Window, enable bool) error {
+		mask := uint32(0)
+		if enable {
+			mask = xproto.EventMaskPropertyChange
+		}
+		return xproto.ChangeWindowAttributesChecked(conn, w, xproto.CwEventMask, []uint32{mask}).Check()
+	}
+	if err := selectProperties(root, true); err != nil {
+		return fmt.Errorf("select root window events: %w", err)
+	}
+
+	notify := func() {
+		windows, err := x.GetWindows()
+		if err != nil {
+			return
+		}
+		for _, w := range windows {
+			if w.IsActive {
+				fn(w)
+				return
+			}
+		}
+	}
+
+	// title changes are only reported for windows we select events on, so follow the active window
+	var watched xproto.Window
+	follow := func() {
+		active, err := getActiveWindow(conn, root, atoms["_NET_ACTIVE_WINDOW"])
+		if err != nil || active == watched {
+			return
+		}
+		if watched != 0 {
+			_ = selectProperties(watched, false) // may already be destroyed
+		}
+		watched = 0
+		if active != 0 && selectProperties(active, true) == nil {
+			watched = active
+		}
+	}
+	follow()
+
+	for {
+		ev, xerr := conn.WaitForEvent()
+		if ev == nil && xerr == nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.New("X server connection closed")
+		}
+		if xerr != nil {
+			continue // e.g. BadWindow for a window that closed before we selected events on it
+		}
+
+		pn, ok := ev.(xproto.PropertyNotifyEvent)
+		if !ok {
+			continue
+		}
+		switch {
+		case pn.Window == root && pn.Atom == atoms["_NET_ACTIVE_WINDOW"]:
+			follow()
+			notify()
+		case pn.Window == watched && (pn.Atom == atoms["_NET_WM_NAME"] || pn.Atom == xproto.AtomWmName):
+			notify()
+		}
+	}
+}
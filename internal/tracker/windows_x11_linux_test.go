@@ -0,0 +1,120 @@
+//go:build linux
+
+package tracker
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// TestX11Windows needs a throwaway X server, as it sets the properties of the root window. Run it with Xvfb:
+//
+//	Xvfb :99 & MTT_TEST_X11_DISPLAY=:99 go test ./internal/tracker -run X11
+//
+// Xvfb has no window manager, so the test maintains the EWMH properties a window manager would.
+func TestX11Windows(t *testing.T) {
+	display := os.Getenv("MTT_TEST_X11_DISPLAY")
+	if display == "" {
+		t.Skip("MTT_TEST_X11_DISPLAY not set")
+	}
+
+	conn, err := xgb.NewConnDisplay(display)
+	if err != nil {
+		t.Fatalf("connect to %s: %v", display, err)
+	}
+	defer conn.Close()
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	atoms, err := internAtoms(conn, "_NET_ACTIVE_WINDOW", "_NET_CLIENT_LIST_STACKING", "_NET_WM_NAME", "_NET_WM_PID", "UTF8_STRING")
+	if err != nil {
+		t.Fatalf("internAtoms() error = %v", err)
+	}
+
+	setProperty := func(w xproto.Window, property, typ xproto.Atom, format byte, data []byte) {
+		t.Helper()
+		length := uint32(len(data)) / uint32(format/8)
+		if err := xproto.ChangePropertyChecked(conn, xproto.PropModeReplace, w, property, typ, format, length, data).Check(); err != nil {
+			t.Fatalf("ChangeProperty() error = %v", err)
+		}
+	}
+	cardinals := func(values ...uint32) []byte {
+		buf := make([]byte, 4*len(values))
+		for i, v := range values {
+			xgb.Put32(buf[4*i:], v)
+		}
+		return buf
+	}
+	newWindow := func(instance, class string) xproto.Window {
+		t.Helper()
+		w, err := xproto.NewWindowId(conn)
+		if err != nil {
+			t.Fatalf("NewWindowId() error = %v", err)
+		}
+		if err := xproto.CreateWindowChecked(conn, screen.RootDepth, w, screen.Root, 0, 0, 100, 100, 0,
+			xproto.WindowClassInputOutput, screen.RootVisual, 0, nil).Check(); err != nil {
+			t.Fatalf("CreateWindow() error = %v", err)
+		}
+		setProperty(w, xproto.AtomWmClass, xproto.AtomString, 8, []byte(instance+"\x00"+class+"\x00"))
+		return w
+	}
+
+	editor := newWindow("code", "Code")
+	setProperty(editor, atoms["_NET_WM_NAME"], atoms["UTF8_STRING"], 8, []byte("main.go - mtt - Visual Studio Code"))
+	setProperty(editor, atoms["_NET_WM_PID"], xproto.AtomCardinal, 32, cardinals(uint32(os.Getpid())))
+	// an old client with only the ICCCM name and no pid
+	terminal := newWindow("xterm", "XTerm")
+	setProperty(terminal, xproto.AtomWmName, xproto.AtomString, 8, []byte("user@host: ~"))
+
+	setProperty(screen.Root, atoms["_NET_CLIENT_LIST_STACKING"], xproto.AtomWindow, 32, cardinals(uint32(editor), uint32(terminal)))
+
+	x, err := NewX11Windows(display)
+	if err != nil {
+		t.Fatalf("NewX11Windows() error = %v", err)
+	}
+	defer x.Close()
+
+	tests := []struct {
+		name   string
+		active xproto.Window
+		want   []WindowInfo
+	}{
+		{
+			name:   "editor focused",
+			active: editor,
+			want: []WindowInfo{
+				{AppName: "Code", RawAppName: "code", WindowTitle: "main.go - mtt - Visual Studio Code", PID: int32(os.Getpid()), IsActive: true},
+				{AppName: "XTerm", RawAppName: "xterm", WindowTitle: "user@host: ~"},
+			},
+		},
+		{
+			name:   "terminal focused",
+			active: terminal,
+			want: []WindowInfo{
+				{AppName: "XTerm", RawAppName: "xterm", WindowTitle: "user@host: ~", IsActive: true},
+				{AppName: "Code", RawAppName: "code", WindowTitle: "main.go - mtt - Visual Studio Code", PID: int32(os.Getpid())},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setProperty(screen.Root, atoms["_NET_ACTIVE_WINDOW"], xproto.AtomWindow, 32, cardinals(uint32(tt.active)))
+
+			got, err := x.GetWindows()
+			if err != nil {
+				t.Fatalf("GetWindows() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetWindows() = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				// the app id and display depend on the test binary and the server's screens
+				got[i].AppID, got[i].Display = "", ""
+				if got[i] != want {
+					t.Errorf("window %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package web_ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+// attrFilterRe matches filters on span attributes, e.g. `attr.domain = github.com`, `attr.git_branch ~ ^feature/`,
+// `attr.incognito = false` or just `attr.channel` for spans that have the attribute.
+var attrFilterRe = regexp.MustCompile(`^attr\.([\w.-]+)\s*(?:(!=|!~|>=|<=|=|~|>|<)\s*(.*))?$`)
+
+// parseFilter parses a filter such as `attr.domain = github.com`, see store.AttrFilter. The value may be quoted.
+func parseFilter(s string) (store.AttrFilter, error) {
+	m := attrFilterRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return store.AttrFilter{}, fmt.Errorf("invalid filter %q, expected attr.<key> <op> <value>", s)
+	}
+
+	f := store.AttrFilter{Key: m[1], Op: m[2], Value: strings.TrimSpace(m[3])}
+	if unquoted, err := strconv.Unquote(f.Value); err == nil {
+		f.Value = unquoted
+	}
+
+	switch f.Op {
+	case "~", "!~":
+		if _, err := regexp.Compile(f.Value); err != nil {
+			return store.AttrFilter{}, fmt.Errorf("invalid filter %q: %w", s, err)
+		}
+	case ">", ">=", "<", "<=":
+		if _, err := strconv.ParseFloat(f.Value, 64); err != nil {
+			return store.AttrFilter{}, fmt.Errorf("invalid filter %q: %s needs a number", s, f.Op)
+		}
+	}
+	return f, nil
+}
+
+// matchNoAttributes reports whether something without attributes, e.g. a manual entry, passes all filters, which it
+// does when they only exclude attribute values.
+func matchNoAttributes(filters []store.AttrFilter) bool {
+	for _, f := range filters {
+		if f.Op != "!=" && f.Op != "!~" {
+			return false
+		}
+	}
+	return true
+}
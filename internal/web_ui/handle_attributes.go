@@ -0,0 +1,45 @@
+package web_ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+type GetAttributesRequest struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+type GetAttributesResponse struct {
+	// Values lists each attribute key and value with the number of spans having it, most common first per key
+	Values []store.SelectAttributeValuesRow `json:"values"`
+}
+
+// handleGetAttributes lists the attribute values recorded in a time range, e.g. to suggest filters.
+func (s *Server) handleGetAttributes(ctx context.Context, in GetAttributesRequest) (*GetAttributesResponse, error) {
+	start := in.Start
+	end := in.End
+
+	// Apply defaults if not provided
+	if start == 0 {
+		start = time.Now().Truncate(24 * time.Hour).Unix()
+	}
+	if end == 0 {
+		end = time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour).Unix()
+	}
+
+	values, err := s.db.SelectAttributeValues(ctx, store.SelectAttributeValuesParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select attribute values: %w", err)
+	}
+
+	return &GetAttributesResponse{
+		Values: values,
+	}, nil
+}
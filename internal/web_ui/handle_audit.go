@@ -0,0 +1,91 @@
+package web_ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/audit"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+	"github.com/fritzkeyzer/mac-time-tracker/pkg/rest"
+)
+
+type GetAuditRequest struct {
+	Limit    int64 `json:"limit"`     // defaults to 100
+	BeforeID int64 `json:"before_id"` // only entries older than this one, for paging
+}
+
+// AuditEntry is an audit log entry, see audit.Target and audit.State for its target, before and after.
+type AuditEntry struct {
+	ID       int64           `json:"id"`
+	At       int64           `json:"at"`
+	Action   string          `json:"action"`
+	Target   json.RawMessage `json:"target"`
+	Before   json.RawMessage `json:"before"`
+	After    json.RawMessage `json:"after"`
+	Undoes   *int64          `json:"undoes,omitempty"`    // the entry an undo reverted
+	UndoneBy *int64          `json:"undone_by,omitempty"` // the undo that reverted this entry
+}
+
+type GetAuditResponse struct {
+	Entries []AuditEntry `json:"entries"` // newest first
+}
+
+func (s *Server) handleGetAudit(ctx context.Context, in GetAuditRequest) (*GetAuditResponse, error) {
+	if in.Limit <= 0 {
+		in.Limit = 100
+	}
+	if in.BeforeID <= 0 {
+		in.BeforeID = math.MaxInt64
+	}
+
+	logs, err := s.db.SelectAuditLogs(ctx, store.SelectAuditLogsParams{
+		BeforeID: in.BeforeID,
+		Limit:    in.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select audit logs: %w", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(logs))
+	for _, entry := range logs {
+		entries = append(entries, AuditEntry{
+			ID:       entry.ID,
+			At:       entry.At,
+			Action:   entry.Action,
+			Target:   json.RawMessage(entry.Target),
+			Before:   json.RawMessage(entry.Before),
+			After:    json.RawMessage(entry.After),
+			Undoes:   entry.Undoes,
+			UndoneBy: entry.UndoneBy,
+		})
+	}
+	return &GetAuditResponse{
+		Entries: entries,
+	}, nil
+}
+
+type UndoAuditRequest struct {
+	ID int64 `json:"id"`
+}
+
+func (s *Server) handleUndoAudit(ctx context.Context, in UndoAuditRequest) (*AuditEntry, error) {
+	entry, err := audit.Undo(ctx, s.db, in.ID)
+	if errors.Is(err, audit.ErrChanged) || errors.Is(err, audit.ErrUndone) {
+		return nil, rest.BadRequest(fmt.Errorf("undo: %w", err))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("undo: %w", err)
+	}
+	return &AuditEntry{
+		ID:     entry.ID,
+		At:     entry.At,
+		Action: entry.Action,
+		Target: json.RawMessage(entry.Target),
+		Before: json.RawMessage(entry.Before),
+		After:  json.RawMessage(entry.After),
+		Undoes: entry.Undoes,
+	}, nil
+}
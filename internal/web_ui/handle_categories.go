@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/fritzkeyzer/mac-time-tracker/internal/audit"
 	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
 )
 
@@ -31,25 +32,37 @@ func (s *Server) handleGetCategories(ctx context.Context) (*GetCategoriesRespons
 
 func (s *Server) handleSaveCategory(ctx context.Context, in store.Category) (*store.Category, error) {
 	if in.ID > 0 {
-		if _, err := s.db.UpdateCategory(ctx, store.UpdateCategoryParams{
-			Name:  in.Name,
-			Color: in.Color,
-			ID:    in.ID,
-		}); err != nil {
-			return nil, fmt.Errorf("update category: %w", err)
+		target := audit.Target{CategoryIDs: []int64{in.ID}}
+		err := audit.Record(ctx, s.db, "category.update", target, func(q *store.Queries) (audit.Target, error) {
+			if _, err := q.UpdateCategory(ctx, store.UpdateCategoryParams{
+				Name:  in.Name,
+				Color: in.Color,
+				ID:    in.ID,
+			}); err != nil {
+				return audit.Target{}, fmt.Errorf("update category: %w", err)
+			}
+			return audit.Target{}, nil
+		})
+		if err != nil {
+			return nil, err
 		}
 		return &in, nil
 	}
 
-	cat, err := s.db.InsertCategory(ctx, store.InsertCategoryParams{
-		Name:  in.Name,
-		Color: in.Color,
+	err := audit.Record(ctx, s.db, "category.create", audit.Target{}, func(q *store.Queries) (audit.Target, error) {
+		cat, err := q.InsertCategory(ctx, store.InsertCategoryParams{
+			Name:  in.Name,
+			Color: in.Color,
+		})
+		if err != nil {
+			return audit.Target{}, fmt.Errorf("insert category: %w", err)
+		}
+		in.ID = cat.ID
+		return audit.Target{CategoryIDs: []int64{cat.ID}}, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("insert category: %w", err)
+		return nil, err
 	}
-
-	in.ID = cat.ID
 	return &in, nil
 }
 
@@ -57,36 +70,80 @@ type DeleteCategoryRequest struct {
 	ID int64 `json:"id"`
 }
 
+// handleDeleteCategory deletes a category with its rules. Foreign keys aren't enforced, so the pins referring to it are
+// cleared here, keeping their project pin.
 func (s *Server) handleDeleteCategory(ctx context.Context, in DeleteCategoryRequest) error {
-	if err := s.db.DeleteCategory(ctx, in.ID); err != nil {
-		return fmt.Errorf("delete category: %w", err)
-	}
-	return nil
+	// the rows referring to it are read in the same transaction as the change, so none are missed
+	return s.db.InTx(ctx, func(q *store.Queries) error {
+		ruleIDs, err := q.SelectCategoryRuleIDs(ctx, in.ID)
+		if err != nil {
+			return fmt.Errorf("select category rule ids: %w", err)
+		}
+		spanIDs, err := q.SelectCategoryPinnedSpanIDs(ctx, &in.ID)
+		if err != nil {
+			return fmt.Errorf("select category pinned span ids: %w", err)
+		}
+
+		target := audit.Target{
+			CategoryIDs:     []int64{in.ID},
+			CategoryRuleIDs: ruleIDs,
+			SpanIDs:         spanIDs,
+		}
+		return audit.Record(ctx, q, "category.delete", target, func(q *store.Queries) (audit.Target, error) {
+			for _, id := range ruleIDs {
+				if err := q.DeleteCategoryRule(ctx, id); err != nil {
+					return audit.Target{}, fmt.Errorf("delete category rule: %w", err)
+				}
+			}
+			if err := q.ClearSpanPinCategory(ctx, &in.ID); err != nil {
+				return audit.Target{}, fmt.Errorf("clear span pin category: %w", err)
+			}
+			if err := q.DeleteEmptySpanPins(ctx); err != nil {
+				return audit.Target{}, fmt.Errorf("delete empty span pins: %w", err)
+			}
+			if err := q.DeleteCategory(ctx, in.ID); err != nil {
+				return audit.Target{}, fmt.Errorf("delete category: %w", err)
+			}
+			return audit.Target{}, nil
+		})
+	})
 }
 
 func (s *Server) handleSaveCategoryRule(ctx context.Context, in store.CategoryRule) (*store.CategoryRule, error) {
 	if in.ID > 0 {
-		if _, err := s.db.UpdateCategoryRule(ctx, store.UpdateCategoryRuleParams{
-			Pattern:    in.Pattern,
-			CategoryID: in.CategoryID,
-			IsActive:   in.IsActive,
-			ID:         in.ID,
-		}); err != nil {
-			return nil, fmt.Errorf("update category rule: %w", err)
+		target := audit.Target{CategoryRuleIDs: []int64{in.ID}}
+		err := audit.Record(ctx, s.db, "category_rule.update", target, func(q *store.Queries) (audit.Target, error) {
+			if _, err := q.UpdateCategoryRule(ctx, store.UpdateCategoryRuleParams{
+				Pattern:    in.Pattern,
+				CategoryID: in.CategoryID,
+				IsActive:   in.IsActive,
+				ID:         in.ID,
+			}); err != nil {
+				return audit.Target{}, fmt.Errorf("update category rule: %w", err)
+			}
+			return audit.Target{}, nil
+		})
+		if err != nil {
+			return nil, err
 		}
 		return &in, nil
 	}
 
-	cRule, err := s.db.InsertCategoryRule(ctx, store.InsertCategoryRuleParams{
-		Pattern:    in.Pattern,
-		CategoryID: in.CategoryID,
-		IsActive:   in.IsActive,
+	err := audit.Record(ctx, s.db, "category_rule.create", audit.Target{}, func(q *store.Queries) (audit.Target, error) {
+		cRule, err := q.InsertCategoryRule(ctx, store.InsertCategoryRuleParams{
+			Pattern:    in.Pattern,
+			CategoryID: in.CategoryID,
+			IsActive:   in.IsActive,
+		})
+		if err != nil {
+			return audit.Target{}, fmt.Errorf("insert category rule: %w", err)
+		}
+		in.ID = cRule.ID
+		return audit.Target{CategoryRuleIDs: []int64{cRule.ID}}, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("insert category rule: %w", err)
+		return nil, err
 	}
-
-	in.ID = cRule.ID
 	return &in, nil
 }
 
@@ -95,8 +152,11 @@ type DeleteCategoryRuleRequest struct {
 }
 
 func (s *Server) handleDeleteCategoryRule(ctx context.Context, in DeleteCategoryRuleRequest) error {
-	if err := s.db.DeleteCategoryRule(ctx, in.ID); err != nil {
-		return fmt.Errorf("delete category rule: %w", err)
-	}
-	return nil
+	target := audit.Target{CategoryRuleIDs: []int64{in.ID}}
+	return audit.Record(ctx, s.db, "category_rule.delete", target, func(q *store.Queries) (audit.Target, error) {
+		if err := q.DeleteCategoryRule(ctx, in.ID); err != nil {
+			return audit.Target{}, fmt.Errorf("delete category rule: %w", err)
+		}
+		return audit.Target{}, nil
+	})
 }
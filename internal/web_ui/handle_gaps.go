@@ -0,0 +1,36 @@
+package web_ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/timer"
+)
+
+type GetGapsResponse struct {
+	Gaps []store.UnaccountedGap `json:"gaps"` // pending gaps, oldest first
+}
+
+func (s *Server) handleGetGaps(ctx context.Context) (*GetGapsResponse, error) {
+	gaps, err := s.db.SelectPendingGaps(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("select pending gaps: %w", err)
+	}
+	return &GetGapsResponse{
+		Gaps: gaps,
+	}, nil
+}
+
+type ResolveGapRequest struct {
+	ID int64 `json:"id"`
+	timer.Resolution
+}
+
+func (s *Server) handleResolveGap(ctx context.Context, in ResolveGapRequest) (*store.UnaccountedGap, error) {
+	gap, err := timer.ResolveGap(ctx, s.db, in.ID, in.Resolution)
+	if err != nil {
+		return nil, fmt.Errorf("resolve gap: %w", err)
+	}
+	return &gap, nil
+}
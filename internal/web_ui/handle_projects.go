@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/fritzkeyzer/mac-time-tracker/internal/audit"
 	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
 )
 
@@ -31,25 +32,37 @@ func (s *Server) handleGetProjects(ctx context.Context) (*GetProjectsResponse, e
 
 func (s *Server) handleSaveProject(ctx context.Context, in store.Project) (*store.Project, error) {
 	if in.ID > 0 {
-		if _, err := s.db.UpdateProject(ctx, store.UpdateProjectParams{
-			Name:  in.Name,
-			Color: in.Color,
-			ID:    in.ID,
-		}); err != nil {
-			return nil, fmt.Errorf("update project: %w", err)
+		target := audit.Target{ProjectIDs: []int64{in.ID}}
+		err := audit.Record(ctx, s.db, "project.update", target, func(q *store.Queries) (audit.Target, error) {
+			if _, err := q.UpdateProject(ctx, store.UpdateProjectParams{
+				Name:  in.Name,
+				Color: in.Color,
+				ID:    in.ID,
+			}); err != nil {
+				return audit.Target{}, fmt.Errorf("update project: %w", err)
+			}
+			return audit.Target{}, nil
+		})
+		if err != nil {
+			return nil, err
 		}
 		return &in, nil
 	}
 
-	cat, err := s.db.InsertProject(ctx, store.InsertProjectParams{
-		Name:  in.Name,
-		Color: in.Color,
+	err := audit.Record(ctx, s.db, "project.create", audit.Target{}, func(q *store.Queries) (audit.Target, error) {
+		cat, err := q.InsertProject(ctx, store.InsertProjectParams{
+			Name:  in.Name,
+			Color: in.Color,
+		})
+		if err != nil {
+			return audit.Target{}, fmt.Errorf("insert project: %w", err)
+		}
+		in.ID = cat.ID
+		return audit.Target{ProjectIDs: []int64{cat.ID}}, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("insert project: %w", err)
+		return nil, err
 	}
-
-	in.ID = cat.ID
 	return &in, nil
 }
 
@@ -57,36 +70,88 @@ type DeleteProjectRequest struct {
 	ID int64 `json:"id"`
 }
 
+// handleDeleteProject deletes a project with its rules. Foreign keys aren't enforced, so the pins and manual entries
+// referring to it are cleared here, keeping their category pin and recorded time.
 func (s *Server) handleDeleteProject(ctx context.Context, in DeleteProjectRequest) error {
-	if err := s.db.DeleteProject(ctx, in.ID); err != nil {
-		return fmt.Errorf("delete project: %w", err)
-	}
-	return nil
+	// the rows referring to it are read in the same transaction as the change, so none are missed
+	return s.db.InTx(ctx, func(q *store.Queries) error {
+		ruleIDs, err := q.SelectProjectRuleIDs(ctx, in.ID)
+		if err != nil {
+			return fmt.Errorf("select project rule ids: %w", err)
+		}
+		spanIDs, err := q.SelectProjectPinnedSpanIDs(ctx, &in.ID)
+		if err != nil {
+			return fmt.Errorf("select project pinned span ids: %w", err)
+		}
+		entryIDs, err := q.SelectProjectManualEntryIDs(ctx, &in.ID)
+		if err != nil {
+			return fmt.Errorf("select project manual entry ids: %w", err)
+		}
+
+		target := audit.Target{
+			ProjectIDs:     []int64{in.ID},
+			ProjectRuleIDs: ruleIDs,
+			SpanIDs:        spanIDs,
+			ManualEntryIDs: entryIDs,
+		}
+		return audit.Record(ctx, q, "project.delete", target, func(q *store.Queries) (audit.Target, error) {
+			for _, id := range ruleIDs {
+				if err := q.DeleteProjectRule(ctx, id); err != nil {
+					return audit.Target{}, fmt.Errorf("delete project rule: %w", err)
+				}
+			}
+			if err := q.ClearSpanPinProject(ctx, &in.ID); err != nil {
+				return audit.Target{}, fmt.Errorf("clear span pin project: %w", err)
+			}
+			if err := q.DeleteEmptySpanPins(ctx); err != nil {
+				return audit.Target{}, fmt.Errorf("delete empty span pins: %w", err)
+			}
+			if err := q.ClearManualEntryProject(ctx, &in.ID); err != nil {
+				return audit.Target{}, fmt.Errorf("clear manual entry project: %w", err)
+			}
+			if err := q.DeleteProject(ctx, in.ID); err != nil {
+				return audit.Target{}, fmt.Errorf("delete project: %w", err)
+			}
+			return audit.Target{}, nil
+		})
+	})
 }
 
 func (s *Server) handleSaveProjectRule(ctx context.Context, in store.ProjectRule) (*store.ProjectRule, error) {
 	if in.ID > 0 {
-		if _, err := s.db.UpdateProjectRule(ctx, store.UpdateProjectRuleParams{
-			Pattern:   in.Pattern,
-			ProjectID: in.ProjectID,
-			IsActive:  in.IsActive,
-			ID:        in.ID,
-		}); err != nil {
-			return nil, fmt.Errorf("update project rule: %w", err)
+		target := audit.Target{ProjectRuleIDs: []int64{in.ID}}
+		err := audit.Record(ctx, s.db, "project_rule.update", target, func(q *store.Queries) (audit.Target, error) {
+			if _, err := q.UpdateProjectRule(ctx, store.UpdateProjectRuleParams{
+				Pattern:   in.Pattern,
+				ProjectID: in.ProjectID,
+				IsActive:  in.IsActive,
+				ID:        in.ID,
+			}); err != nil {
+				return audit.Target{}, fmt.Errorf("update project rule: %w", err)
+			}
+			return audit.Target{}, nil
+		})
+		if err != nil {
+			return nil, err
 		}
 		return &in, nil
 	}
 
-	pRule, err := s.db.InsertProjectRule(ctx, store.InsertProjectRuleParams{
-		Pattern:   in.Pattern,
-		ProjectID: in.ProjectID,
-		IsActive:  in.IsActive,
+	err := audit.Record(ctx, s.db, "project_rule.create", audit.Target{}, func(q *store.Queries) (audit.Target, error) {
+		pRule, err := q.InsertProjectRule(ctx, store.InsertProjectRuleParams{
+			Pattern:   in.Pattern,
+			ProjectID: in.ProjectID,
+			IsActive:  in.IsActive,
+		})
+		if err != nil {
+			return audit.Target{}, fmt.Errorf("insert project rule: %w", err)
+		}
+		in.ID = pRule.ID
+		return audit.Target{ProjectRuleIDs: []int64{pRule.ID}}, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("insert project rule: %w", err)
+		return nil, err
 	}
-
-	in.ID = pRule.ID
 	return &in, nil
 }
 
@@ -95,8 +160,11 @@ type DeleteProjectRuleRequest struct {
 }
 
 func (s *Server) handleDeleteProjectRule(ctx context.Context, in DeleteProjectRuleRequest) error {
-	if err := s.db.DeleteProjectRule(ctx, in.ID); err != nil {
-		return fmt.Errorf("delete project rule: %w", err)
-	}
-	return nil
+	target := audit.Target{ProjectRuleIDs: []int64{in.ID}}
+	return audit.Record(ctx, s.db, "project_rule.delete", target, func(q *store.Queries) (audit.Target, error) {
+		if err := q.DeleteProjectRule(ctx, in.ID); err != nil {
+			return audit.Target{}, fmt.Errorf("delete project rule: %w", err)
+		}
+		return audit.Target{}, nil
+	})
 }
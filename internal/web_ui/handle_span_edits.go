@@ -0,0 +1,123 @@
+package web_ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/audit"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/spanedit"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+)
+
+type SplitSpanRequest struct {
+	ID int64 `json:"id"`
+	At int64 `json:"at"` // Unix timestamp within the span
+}
+
+type SplitSpanResponse struct {
+	First  store.Span `json:"first"`
+	Second store.Span `json:"second"`
+}
+
+func (s *Server) handleSplitSpan(ctx context.Context, in SplitSpanRequest) (*SplitSpanResponse, error) {
+	var first, second store.Span
+	target := audit.Target{SpanIDs: []int64{in.ID}}
+	err := audit.Record(ctx, s.db, "span.split", target, func(q *store.Queries) (audit.Target, error) {
+		var err error
+		first, second, err = spanedit.Split(ctx, q, in.ID, in.At)
+		return audit.Target{SpanIDs: []int64{second.ID}}, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("split span: %w", err)
+	}
+	return &SplitSpanResponse{
+		First:  first,
+		Second: second,
+	}, nil
+}
+
+type MergeSpansRequest struct {
+	ID      int64 `json:"id"`
+	OtherID int64 `json:"other_id"`
+}
+
+func (s *Server) handleMergeSpans(ctx context.Context, in MergeSpansRequest) (*store.Span, error) {
+	var span store.Span
+	target := audit.Target{SpanIDs: []int64{in.ID, in.OtherID}}
+	err := audit.Record(ctx, s.db, "span.merge", target, func(q *store.Queries) (audit.Target, error) {
+		var err error
+		span, err = spanedit.Merge(ctx, q, in.ID, in.OtherID)
+		return audit.Target{}, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("merge spans: %w", err)
+	}
+	return &span, nil
+}
+
+type TrimSpanRequest struct {
+	ID      int64 `json:"id"`
+	StartAt int64 `json:"start_at"`
+	EndAt   int64 `json:"end_at"`
+}
+
+func (s *Server) handleTrimSpan(ctx context.Context, in TrimSpanRequest) (*store.Span, error) {
+	var span store.Span
+	target := audit.Target{SpanIDs: []int64{in.ID}}
+	err := audit.Record(ctx, s.db, "span.trim", target, func(q *store.Queries) (audit.Target, error) {
+		var err error
+		span, err = spanedit.Trim(ctx, q, in.ID, in.StartAt, in.EndAt)
+		return audit.Target{}, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("trim span: %w", err)
+	}
+	return &span, nil
+}
+
+type DeleteSpanRequest struct {
+	ID int64 `json:"id"`
+}
+
+func (s *Server) handleDeleteSpan(ctx context.Context, in DeleteSpanRequest) error {
+	target := audit.Target{SpanIDs: []int64{in.ID}}
+	err := audit.Record(ctx, s.db, "span.delete", target, func(q *store.Queries) (audit.Target, error) {
+		return audit.Target{}, spanedit.Delete(ctx, q, in.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("delete span: %w", err)
+	}
+	return nil
+}
+
+type PinSpansRequest struct {
+	SpanIDs    []int64 `json:"span_ids"`
+	ProjectID  *int64  `json:"project_id"`  // nil leaves the project to the rules
+	CategoryID *int64  `json:"category_id"` // nil leaves the category to the rules
+}
+
+func (s *Server) handlePinSpans(ctx context.Context, in PinSpansRequest) error {
+	target := audit.Target{SpanIDs: in.SpanIDs}
+	err := audit.Record(ctx, s.db, "span.pin", target, func(q *store.Queries) (audit.Target, error) {
+		return audit.Target{}, spanedit.Pin(ctx, q, in.SpanIDs, in.ProjectID, in.CategoryID)
+	})
+	if err != nil {
+		return fmt.Errorf("pin spans: %w", err)
+	}
+	return nil
+}
+
+type UnpinSpansRequest struct {
+	SpanIDs []int64 `json:"span_ids"`
+}
+
+func (s *Server) handleUnpinSpans(ctx context.Context, in UnpinSpansRequest) error {
+	target := audit.Target{SpanIDs: in.SpanIDs}
+	err := audit.Record(ctx, s.db, "span.unpin", target, func(q *store.Queries) (audit.Target, error) {
+		return audit.Target{}, spanedit.Unpin(ctx, q, in.SpanIDs)
+	})
+	if err != nil {
+		return fmt.Errorf("unpin spans: %w", err)
+	}
+	return nil
+}
@@ -6,30 +6,96 @@ import (
 	"regexp"
 	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/timer"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/tracker"
 )
 
 type GetTimelineRequest struct {
 	Start int64 `json:"from"`
 	End   int64 `json:"to"`
+	// Filters keep the spans matching all of them, e.g. "attr.domain = github.com", see parseFilter
+	Filters []string `json:"filters"`
 }
 
 type TimelineSpan struct {
-	Span       store.Span       `json:"span"`
-	Categories []store.Category `json:"categories,omitempty"`
-	Projects   []store.Project  `json:"projects,omitempty"`
+	Span       store.Span             `json:"span"`
+	BrowserTab *store.SpanBrowserTab  `json:"browser_tab,omitempty"`
+	Terminal   *store.TerminalContext `json:"terminal,omitempty"`
+	Heartbeats []store.Heartbeat      `json:"heartbeats,omitempty"`
+	Attributes map[string]any         `json:"attributes,omitempty"` // typed values, see tracker.AttributeValue
+	Categories []store.Category       `json:"categories,omitempty"`
+	Projects   []store.Project        `json:"projects,omitempty"`
+	// Manual is set on the spans of manual entries, which replace the spans and away time they overlap
+	Manual *store.SelectManualEntriesRow `json:"manual,omitempty"`
+	// Pin is the project and/or category pinned to the span, which override all other sources
+	Pin *store.SpanPin `json:"pin,omitempty"`
+	// Assignments say where each of the projects and categories came from
+	Assignments []Assignment `json:"assignments,omitempty"`
+}
+
+// Sources of a span's projects and categories
+const (
+	SourcePin       = "pin"       // pinned to the span with /api/spans/pin
+	SourceManual    = "manual"    // the project of a manual entry, or the meeting category
+	SourceHeartbeat = "heartbeat" // an editor heartbeat naming the project
+	SourceTitle     = "title"     // the project parsed from the window title
+	SourceRule      = "rule"      // a project or category rule
+)
+
+// Assignment is a project or category of a span and where it came from.
+type Assignment struct {
+	ProjectID  int64  `json:"project_id,omitempty"`
+	CategoryID int64  `json:"category_id,omitempty"`
+	Source     string `json:"source"`
+	RuleID     int64  `json:"rule_id,omitempty"` // the matching rule, for SourceRule
+}
+
+// addProject adds a project from source, unless the span already has it or a project is pinned to the span.
+func (ts *TimelineSpan) addProject(project store.Project, source string, ruleID int64) {
+	if source != SourcePin && ts.Pin != nil && ts.Pin.ProjectID != nil {
+		return
+	}
+	if slices.ContainsFunc(ts.Projects, func(p store.Project) bool { return p.ID == project.ID }) {
+		return
+	}
+	ts.Projects = append(ts.Projects, project)
+	ts.Assignments = append(ts.Assignments, Assignment{ProjectID: project.ID, Source: source, RuleID: ruleID})
+}
+
+// addCategory adds a category from source, unless the span already has it or a category is pinned to the span.
+func (ts *TimelineSpan) addCategory(category store.Category, source string, ruleID int64) {
+	if source != SourcePin && ts.Pin != nil && ts.Pin.CategoryID != nil {
+		return
+	}
+	if slices.ContainsFunc(ts.Categories, func(c store.Category) bool { return c.ID == category.ID }) {
+		return
+	}
+	ts.Categories = append(ts.Categories, category)
+	ts.Assignments = append(ts.Assignments, Assignment{CategoryID: category.ID, Source: source, RuleID: ruleID})
 }
 
 type GetTimelineResponse struct {
-	Spans []TimelineSpan `json:"spans"`
+	Spans []TimelineSpan   `json:"spans"`
+	Away  []store.AwaySpan `json:"away"`
 }
 
 func (s *Server) handleGetTimeline(ctx context.Context, in GetTimelineRequest) (*GetTimelineResponse, error) {
 	start := in.Start
 	end := in.End
 
+	filters := make([]store.AttrFilter, 0, len(in.Filters))
+	for _, expr := range in.Filters {
+		f, err := parseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
 	// Apply defaults if not provided
 	if start == 0 {
 		start = time.Now().Truncate(24 * time.Hour).Unix()
@@ -38,10 +104,10 @@ func (s *Server) handleGetTimeline(ctx context.Context, in GetTimelineRequest) (
 		end = time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour).Unix()
 	}
 
-	spans, err := s.db.SelectSpans(ctx, store.SelectSpansParams{
+	spans, err := s.db.SelectFilteredSpans(ctx, store.SelectSpansParams{
 		StartAt: start,
 		EndAt:   end,
-	})
+	}, filters)
 	if err != nil {
 		return nil, fmt.Errorf("select spans: %w", err)
 	}
@@ -55,12 +121,186 @@ func (s *Server) handleGetTimeline(ctx context.Context, in GetTimelineRequest) (
 		return nil, fmt.Errorf("select category rules: %w", err)
 	}
 
+	awaySpans, err := s.db.SelectAwaySpans(ctx, store.SelectAwaySpansParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select away spans: %w", err)
+	}
+
+	browserTabs, err := s.db.SelectSpanBrowserTabs(ctx, store.SelectSpanBrowserTabsParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select span browser tabs: %w", err)
+	}
+	browserTabBySpan := make(map[int64]*store.SpanBrowserTab, len(browserTabs))
+	for i := range browserTabs {
+		browserTabBySpan[browserTabs[i].SpanID] = &browserTabs[i]
+	}
+
+	terminalContexts, err := s.db.SelectTerminalContexts(ctx, store.SelectTerminalContextsParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select terminal contexts: %w", err)
+	}
+	terminalBySpan := make(map[int64]*store.TerminalContext, len(terminalContexts))
+	for i := range terminalContexts {
+		terminalBySpan[terminalContexts[i].SpanID] = &terminalContexts[i]
+	}
+
+	attributes, err := s.db.SelectSpanAttributes(ctx, store.SelectSpanAttributesParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select span attributes: %w", err)
+	}
+	attributesBySpan := make(map[int64]map[string]any)
+	for _, attr := range attributes {
+		if attributesBySpan[attr.SpanID] == nil {
+			attributesBySpan[attr.SpanID] = make(map[string]any)
+		}
+		attributesBySpan[attr.SpanID][attr.Key] = tracker.AttributeValue(attr.Value, attr.Type)
+	}
+
+	heartbeats, err := s.db.SelectHeartbeats(ctx, store.SelectHeartbeatsParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select heartbeats: %w", err)
+	}
+
+	projects, err := s.db.SelectProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("select projects: %w", err)
+	}
+	projectByName := make(map[string]store.Project, len(projects))
+	projectByID := make(map[int64]store.Project, len(projects))
+	for _, project := range projects {
+		projectByName[strings.ToLower(project.Name)] = project
+		projectByID[project.ID] = project
+	}
+
+	categories, err := s.db.SelectCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("select categories: %w", err)
+	}
+	categoryByID := make(map[int64]store.Category, len(categories))
+	var meeting *store.Category
+	for i := range categories {
+		categoryByID[categories[i].ID] = categories[i]
+		if strings.EqualFold(categories[i].Name, timer.KindMeeting) {
+			meeting = &categories[i]
+		}
+	}
+
+	pins, err := s.db.SelectSpanPins(ctx, store.SelectSpanPinsParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select span pins: %w", err)
+	}
+	pinBySpan := make(map[int64]*store.SpanPin, len(pins))
+	for i := range pins {
+		// a pin to a deleted project or category no longer overrides anything
+		if id := pins[i].ProjectID; id != nil {
+			if _, ok := projectByID[*id]; !ok {
+				pins[i].ProjectID = nil
+			}
+		}
+		if id := pins[i].CategoryID; id != nil {
+			if _, ok := categoryByID[*id]; !ok {
+				pins[i].CategoryID = nil
+			}
+		}
+		if pins[i].ProjectID != nil || pins[i].CategoryID != nil {
+			pinBySpan[pins[i].SpanID] = &pins[i]
+		}
+	}
+
+	manualEntries, err := s.db.SelectManualEntries(ctx, store.SelectManualEntriesParams{
+		StartAt: start,
+		EndAt:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select manual entries: %w", err)
+	}
+	manual := manualIntervals(manualEntries, time.Now().Unix())
+
 	data := &GetTimelineResponse{}
 
+	// manual entries take precedence, so only the parts of spans and away spans outside of them are kept
 	for _, span := range spans {
-		data.Spans = append(data.Spans, TimelineSpan{
-			Span: span,
-		})
+		for _, part := range subtractIntervals(span.StartAt, span.EndAt, manual) {
+			span.StartAt, span.EndAt = part.start, part.end
+			data.Spans = append(data.Spans, TimelineSpan{
+				Span:       span,
+				BrowserTab: browserTabBySpan[span.ID],
+				Terminal:   terminalBySpan[span.ID],
+				Attributes: attributesBySpan[span.ID],
+				Pin:        pinBySpan[span.ID],
+			})
+		}
+	}
+
+	// pins override the other sources, which skip whatever is pinned
+	for i := range data.Spans {
+		pin := data.Spans[i].Pin
+		if pin == nil {
+			continue
+		}
+		if pin.ProjectID != nil {
+			data.Spans[i].addProject(projectByID[*pin.ProjectID], SourcePin, 0)
+		}
+		if pin.CategoryID != nil {
+			data.Spans[i].addCategory(categoryByID[*pin.CategoryID], SourcePin, 0)
+		}
+	}
+	for _, away := range awaySpans {
+		for _, part := range subtractIntervals(away.StartAt, away.EndAt, manual) {
+			away.StartAt, away.EndAt = part.start, part.end
+			data.Away = append(data.Away, away)
+		}
+	}
+
+	// editor heartbeats name their project, which beats guessing it from the window title. The heartbeats are sorted
+	// by time, so walking the spans by start time skips the heartbeats before each span once
+	byStart := make([]int, len(data.Spans))
+	for i := range byStart {
+		byStart[i] = i
+	}
+	sort.SliceStable(byStart, func(a, b int) bool {
+		return data.Spans[byStart[a]].Span.StartAt < data.Spans[byStart[b]].Span.StartAt
+	})
+	next := 0 // first heartbeat not before the span
+	for _, i := range byStart {
+		for next < len(heartbeats) && heartbeats[next].Time < data.Spans[i].Span.StartAt {
+			next++
+		}
+		for _, hb := range heartbeats[next:] {
+			if hb.Time > data.Spans[i].Span.EndAt {
+				break
+			}
+			data.Spans[i].Heartbeats = append(data.Spans[i].Heartbeats, hb)
+
+			if project, ok := projectByName[strings.ToLower(hb.Project)]; ok {
+				data.Spans[i].addProject(project, SourceHeartbeat, 0)
+			}
+		}
+	}
+	// as do titles naming the project, e.g. of JetBrains IDEs
+	for i := range data.Spans {
+		name, _ := data.Spans[i].Attributes[tracker.AttrProject].(string)
+		if project, ok := projectByName[strings.ToLower(name)]; ok {
+			data.Spans[i].addProject(project, SourceTitle, 0)
+		}
 	}
 	for _, rule := range projectRules {
 		if !rule.IsActive {
@@ -68,17 +308,12 @@ func (s *Server) handleGetTimeline(ctx context.Context, in GetTimelineRequest) (
 		}
 		re := regexp.MustCompile(rule.Pattern)
 		for i := range data.Spans {
-			if slices.ContainsFunc(data.Spans[i].Projects, func(project store.Project) bool {
-				return project.ID == rule.ProjectID
-			}) {
-				continue
-			}
-			if re.MatchString(data.Spans[i].Span.AppName + " " + data.Spans[i].Span.WindowTitle) {
-				data.Spans[i].Projects = append(data.Spans[i].Projects, store.Project{
+			if ruleMatches(re, data.Spans[i]) {
+				data.Spans[i].addProject(store.Project{
 					ID:    rule.ProjectID,
 					Name:  rule.Name,
 					Color: rule.Color,
-				})
+				}, SourceRule, rule.ID)
 			}
 		}
 	}
@@ -88,31 +323,67 @@ func (s *Server) handleGetTimeline(ctx context.Context, in GetTimelineRequest) (
 		}
 		re := regexp.MustCompile(rule.Pattern)
 		for i := range data.Spans {
-			if slices.ContainsFunc(data.Spans[i].Categories, func(category store.Category) bool {
-				return category.ID == rule.CategoryID
-			}) {
-				continue
-			}
-			if re.MatchString(data.Spans[i].Span.AppName + " " + data.Spans[i].Span.WindowTitle) {
-				data.Spans[i].Categories = append(data.Spans[i].Categories, store.Category{
+			if ruleMatches(re, data.Spans[i]) {
+				data.Spans[i].addCategory(store.Category{
 					ID:    rule.CategoryID,
 					Name:  rule.Name,
 					Color: rule.Color,
-				})
+				}, SourceRule, rule.ID)
 			}
 		}
 	}
 
+	// added after the rules, a manual entry only counts towards its own project
+	for i, entry := range manualEntries {
+		if entry.Kind == timer.KindBreak {
+			data.Away = append(data.Away, store.AwaySpan{
+				Kind:    timer.KindBreak,
+				Reason:  ManualAwayReason,
+				StartAt: manual[i].start,
+				EndAt:   manual[i].end,
+			})
+			continue
+		}
+		if matchNoAttributes(filters) {
+			data.Spans = append(data.Spans, manualSpan(manualEntries[i], manual[i], meeting))
+		}
+	}
+
 	return data, nil
 }
 
+// ruleMatches reports whether a rule pattern matches "<app name> <window title>" or, on their own, the span's app id,
+// browser tab domain and URL, terminal git root and working directory, or "<key>=<value>" of an attribute. Matching the
+// app id lets rules tell apart apps sharing a display name, e.g. `^com\.microsoft\.VSCodeInsiders$`, matching the domain
+// splits browsing time, e.g. `^github\.com$`, matching the git root splits terminal time, e.g.
+// `/src/mac-time-tracker$`, and matching attributes uses the parsed title, e.g. `^channel=incidents$`.
+func ruleMatches(re *regexp.Regexp, ts TimelineSpan) bool {
+	if re.MatchString(ts.Span.AppName + " " + ts.Span.WindowTitle) {
+		return true
+	}
+	for key, value := range ts.Attributes {
+		if re.MatchString(fmt.Sprintf("%s=%v", key, value)) {
+			return true
+		}
+	}
+	if ts.Span.AppID != "" && re.MatchString(ts.Span.AppID) {
+		return true
+	}
+	if ts.BrowserTab != nil && (re.MatchString(ts.BrowserTab.Domain) || re.MatchString(ts.BrowserTab.Url)) {
+		return true
+	}
+	return ts.Terminal != nil && (re.MatchString(ts.Terminal.GitRoot) || re.MatchString(ts.Terminal.Cwd))
+}
+
 type GetOverviewRequest struct {
-	Start int64 `json:"start"`
-	End   int64 `json:"end"`
+	Start   int64    `json:"start"`
+	End     int64    `json:"end"`
+	Filters []string `json:"filters"` // see GetTimelineRequest
 }
 
 type AppOverview struct {
 	Name         string       `json:"name"`
+	AppID        string       `json:"app_id"`
 	Spans        []store.Span `json:"spans"`
 	TotalSeconds int64        `json:"total_seconds"`
 }
@@ -129,17 +400,25 @@ type CategoryOverview struct {
 	TotalSeconds int64          `json:"total_seconds"`
 }
 
+type AwayOverview struct {
+	Kind         string `json:"kind"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
 type GetOverviewResponse struct {
-	TotalSeconds int64              `json:"total_seconds"`
-	Apps         []AppOverview      `json:"apps"`
-	Projects     []ProjectOverview  `json:"projects"`
-	Categories   []CategoryOverview `json:"categories"`
+	TotalSeconds     int64              `json:"total_seconds"`
+	AwayTotalSeconds int64              `json:"away_total_seconds"`
+	Apps             []AppOverview      `json:"apps"`
+	Projects         []ProjectOverview  `json:"projects"`
+	Categories       []CategoryOverview `json:"categories"`
+	Away             []AwayOverview     `json:"away"`
 }
 
 func (s *Server) handleGetOverview(ctx context.Context, in GetOverviewRequest) (*GetOverviewResponse, error) {
 	timelineData, err := s.handleGetTimeline(ctx, GetTimelineRequest{
-		Start: in.Start,
-		End:   in.End,
+		Start:   in.Start,
+		End:     in.End,
+		Filters: in.Filters,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("get timeline data: %w", err)
@@ -151,17 +430,20 @@ func (s *Server) handleGetOverview(ctx context.Context, in GetOverviewRequest) (
 		totalSeconds += ts.Span.EndAt - ts.Span.StartAt
 	}
 
-	// Group by app
-	appMap := make(map[string]*AppOverview)
+	// Group by app (apps sharing a display name are told apart by their app id)
+	type appKey struct{ name, appID string }
+	appMap := make(map[appKey]*AppOverview)
 	for _, ts := range timelineData.Spans {
-		if _, ok := appMap[ts.Span.AppName]; !ok {
-			appMap[ts.Span.AppName] = &AppOverview{
+		key := appKey{ts.Span.AppName, ts.Span.AppID}
+		if _, ok := appMap[key]; !ok {
+			appMap[key] = &AppOverview{
 				Name:  ts.Span.AppName,
+				AppID: ts.Span.AppID,
 				Spans: []store.Span{},
 			}
 		}
-		appMap[ts.Span.AppName].Spans = append(appMap[ts.Span.AppName].Spans, ts.Span)
-		appMap[ts.Span.AppName].TotalSeconds += ts.Span.EndAt - ts.Span.StartAt
+		appMap[key].Spans = append(appMap[key].Spans, ts.Span)
+		appMap[key].TotalSeconds += ts.Span.EndAt - ts.Span.StartAt
 	}
 
 	// Group by project (a span can have multiple projects)
@@ -194,6 +476,19 @@ func (s *Server) handleGetOverview(ctx context.Context, in GetOverviewRequest) (
 		}
 	}
 
+	// Group away time by kind
+	var awayTotalSeconds int64
+	awayMap := make(map[string]*AwayOverview)
+	for _, as := range timelineData.Away {
+		if _, ok := awayMap[as.Kind]; !ok {
+			awayMap[as.Kind] = &AwayOverview{
+				Kind: as.Kind,
+			}
+		}
+		awayMap[as.Kind].TotalSeconds += as.EndAt - as.StartAt
+		awayTotalSeconds += as.EndAt - as.StartAt
+	}
+
 	// Convert maps to slices
 	apps := make([]AppOverview, 0, len(appMap))
 	for _, app := range appMap {
@@ -210,6 +505,11 @@ func (s *Server) handleGetOverview(ctx context.Context, in GetOverviewRequest) (
 		categories = append(categories, *cat)
 	}
 
+	away := make([]AwayOverview, 0, len(awayMap))
+	for _, a := range awayMap {
+		away = append(away, *a)
+	}
+
 	sort.Slice(apps, func(i, j int) bool {
 		return apps[i].TotalSeconds > apps[j].TotalSeconds
 	})
@@ -219,11 +519,16 @@ func (s *Server) handleGetOverview(ctx context.Context, in GetOverviewRequest) (
 	sort.Slice(categories, func(i, j int) bool {
 		return categories[i].TotalSeconds > categories[j].TotalSeconds
 	})
+	sort.Slice(away, func(i, j int) bool {
+		return away[i].TotalSeconds > away[j].TotalSeconds
+	})
 
 	return &GetOverviewResponse{
-		TotalSeconds: totalSeconds,
-		Apps:         apps,
-		Projects:     projects,
-		Categories:   categories,
+		TotalSeconds:     totalSeconds,
+		AwayTotalSeconds: awayTotalSeconds,
+		Apps:             apps,
+		Projects:         projects,
+		Categories:       categories,
+		Away:             away,
 	}, nil
 }
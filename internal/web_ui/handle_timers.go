@@ -0,0 +1,150 @@
+package web_ui
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fritzkeyzer/mac-time-tracker/internal/store"
+	"github.com/fritzkeyzer/mac-time-tracker/internal/timer"
+	"github.com/fritzkeyzer/mac-time-tracker/pkg/rest"
+)
+
+const (
+	// ManualAppName is the app name of the timeline spans of manual entries.
+	ManualAppName = "Manual"
+	// ManualAwayReason is the reason of the away spans of manual breaks.
+	ManualAwayReason = "manual_break"
+)
+
+type GetTimersResponse struct {
+	Running *store.SelectRunningManualEntryRow `json:"running"` // nil when no timer is running
+}
+
+func (s *Server) handleGetTimers(ctx context.Context) (*GetTimersResponse, error) {
+	running, err := s.db.SelectRunningManualEntry(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &GetTimersResponse{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select running manual entry: %w", err)
+	}
+	return &GetTimersResponse{
+		Running: &running,
+	}, nil
+}
+
+type StartTimerRequest struct {
+	ProjectID int64  `json:"project_id"`
+	Note      string `json:"note"`
+}
+
+type StartTimerResponse struct {
+	Started store.ManualEntry  `json:"started"`
+	Stopped *store.ManualEntry `json:"stopped,omitempty"` // the timer that was running before
+}
+
+func (s *Server) handleStartTimer(ctx context.Context, in StartTimerRequest) (*StartTimerResponse, error) {
+	if _, err := s.db.SelectProject(ctx, in.ProjectID); errors.Is(err, sql.ErrNoRows) {
+		return nil, rest.BadRequest(fmt.Errorf("project %d not found", in.ProjectID))
+	} else if err != nil {
+		return nil, fmt.Errorf("select project: %w", err)
+	}
+
+	started, stopped, err := timer.Start(ctx, s.db, in.ProjectID, in.Note, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("start timer: %w", err)
+	}
+
+	resp := &StartTimerResponse{
+		Started: started,
+	}
+	if stopped.ID > 0 {
+		resp.Stopped = &stopped
+	}
+	return resp, nil
+}
+
+func (s *Server) handleStopTimer(ctx context.Context) (*store.ManualEntry, error) {
+	entry, err := timer.Stop(ctx, s.db, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("stop timer: %w", err)
+	}
+	return &entry, nil
+}
+
+// manualSpan returns the timeline span of a manual entry of kind project or meeting, covering iv. Meetings get the
+// meeting category, if there is one.
+func manualSpan(entry store.SelectManualEntriesRow, iv interval, meeting *store.Category) TimelineSpan {
+	ts := TimelineSpan{
+		Span: store.Span{
+			AppName:     ManualAppName,
+			WindowTitle: entry.Note,
+			StartAt:     iv.start,
+			EndAt:       iv.end,
+		},
+		Manual: &entry,
+	}
+	if entry.ProjectID != nil && entry.Name != nil && entry.Color != nil {
+		ts.addProject(store.Project{
+			ID:    *entry.ProjectID,
+			Name:  *entry.Name,
+			Color: *entry.Color,
+		}, SourceManual, 0)
+		if ts.Span.WindowTitle == "" {
+			ts.Span.WindowTitle = *entry.Name
+		}
+	}
+	if entry.Kind == timer.KindMeeting {
+		if meeting != nil {
+			ts.addCategory(*meeting, SourceManual, 0)
+		}
+		if ts.Span.WindowTitle == "" {
+			ts.Span.WindowTitle = "Meeting"
+		}
+	}
+	return ts
+}
+
+// interval is a [start, end) range of Unix timestamps.
+type interval struct {
+	start, end int64
+}
+
+// manualIntervals returns the time covered by manual entries, a running timer covers the time until now.
+func manualIntervals(entries []store.SelectManualEntriesRow, now int64) []interval {
+	intervals := make([]interval, 0, len(entries))
+	for _, entry := range entries {
+		end := entry.EndAt
+		if end == 0 {
+			end = max(now, entry.StartAt)
+		}
+		intervals = append(intervals, interval{entry.StartAt, end})
+	}
+	return intervals
+}
+
+// subtractIntervals returns the parts of [start, end] not covered by cover. A range that no interval overlaps is
+// returned as is, so zero-length spans are kept.
+func subtractIntervals(start, end int64, cover []interval) []interval {
+	remaining := []interval{{start, end}}
+	for _, c := range cover {
+		var next []interval
+		for _, r := range remaining {
+			if c.start >= r.end || c.end <= r.start {
+				next = append(next, r)
+				continue
+			}
+			if c.start > r.start {
+				next = append(next, interval{r.start, c.start})
+			}
+			if c.end < r.end {
+				next = append(next, interval{c.end, r.end})
+			}
+		}
+		remaining = next
+	}
+	return remaining
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os/exec"
 	"strings"
@@ -20,13 +21,13 @@ var content embed.FS
 
 type Server struct {
 	db   *store.Queries
-	port string
+	addr string
 }
 
-func NewServer(db *store.Queries, port string) *Server {
+func NewServer(db *store.Queries, addr string) *Server {
 	return &Server{
 		db:   db,
-		port: port,
+		addr: addr,
 	}
 }
 
@@ -39,6 +40,13 @@ func (s *Server) Start(ctx context.Context) error {
 	// Span Endpoints
 	mux.Handle("/api/timeline", gz(rest.WrapJSONInOut(s.handleGetTimeline)))
 	mux.Handle("/api/overview", gz(rest.WrapJSONInOut(s.handleGetOverview)))
+	mux.Handle("/api/attributes", gz(rest.WrapJSONInOut(s.handleGetAttributes)))
+	mux.Handle("/api/spans/split", gz(rest.WrapJSONInOut(s.handleSplitSpan)))
+	mux.Handle("/api/spans/merge", gz(rest.WrapJSONInOut(s.handleMergeSpans)))
+	mux.Handle("/api/spans/trim", gz(rest.WrapJSONInOut(s.handleTrimSpan)))
+	mux.Handle("/api/spans/delete", gz(rest.WrapJSONIn(s.handleDeleteSpan)))
+	mux.Handle("/api/spans/pin", gz(rest.WrapJSONIn(s.handlePinSpans)))
+	mux.Handle("/api/spans/unpin", gz(rest.WrapJSONIn(s.handleUnpinSpans)))
 
 	// Category Endpoints
 	mux.Handle("/api/categories", gz(rest.WrapJSONOut(s.handleGetCategories)))
@@ -54,16 +62,35 @@ func (s *Server) Start(ctx context.Context) error {
 	mux.Handle("/api/projects/rules/save", gz(rest.WrapJSONInOut(s.handleSaveProjectRule)))
 	mux.Handle("/api/projects/rules/delete", gz(rest.WrapJSONIn(s.handleDeleteProjectRule)))
 
-	addr := ":" + s.port
-	slog.Info("Starting web server", "addr", addr)
+	// Manual Timer Endpoints
+	mux.Handle("/api/timers", gz(rest.WrapJSONOut(s.handleGetTimers)))
+	mux.Handle("/api/timers/start", gz(rest.WrapJSONInOut(s.handleStartTimer)))
+	mux.Handle("/api/timers/stop", gz(rest.WrapJSONOut(s.handleStopTimer)))
 
-	// Open browser
-	url := fmt.Sprintf("http://localhost%s/index.html", addr)
+	// Unaccounted Gap Endpoints
+	mux.Handle("/api/gaps", gz(rest.WrapJSONOut(s.handleGetGaps)))
+	mux.Handle("/api/gaps/resolve", gz(rest.WrapJSONInOut(s.handleResolveGap)))
+
+	// Audit Endpoints
+	mux.Handle("/api/audit", gz(rest.WrapJSONInOut(s.handleGetAudit)))
+	mux.Handle("/api/audit/undo", gz(rest.WrapJSONInOut(s.handleUndoAudit)))
+
+	slog.Info("Starting web server", "addr", s.addr)
+
+	// Open browser (on localhost when listening on all interfaces)
+	host, port, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("invalid web address: %w", err)
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	url := fmt.Sprintf("http://%s/index.html", net.JoinHostPort(host, port))
 	if err := exec.Command("open", url).Start(); err != nil {
 		slog.Warn("Failed to open browser", "error", err)
 	}
 
-	return http.ListenAndServe(addr, mux)
+	return http.ListenAndServe(s.addr, mux)
 }
 
 // gzipResponseWriter wraps http.ResponseWriter to compress responses
@@ -0,0 +1,80 @@
+import { ref, onMounted } from 'vue';
+import { useGapsStore } from '../stores/useGapsStore.js';
+import { useProjectsStore } from '../stores/useProjectsStore.js';
+
+// Asks what the user was doing during the away periods recorded when they returned
+export default {
+    setup() {
+        const store = useGapsStore();
+        const projectsStore = useProjectsStore();
+
+        // Selected project per gap id
+        const selectedProject = ref({});
+
+        const formatRange = (gap) => {
+            const start = new Date(gap.start_at * 1000);
+            const end = new Date(gap.end_at * 1000);
+            const time = (d) => d.toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
+            const day = start.toLocaleDateString([], { weekday: 'short' });
+            return `${day} ${time(start)} - ${time(end)}`;
+        };
+
+        const formatDuration = (gap) => {
+            const seconds = gap.end_at - gap.start_at;
+            const h = Math.floor(seconds / 3600);
+            const m = Math.floor((seconds % 3600) / 60);
+            if (h > 0) return `${h}h ${m}m`;
+            return `${m}m`;
+        };
+
+        const resolve = async (gap, kind) => {
+            const projectId = kind === 'project' ? Number(selectedProject.value[gap.id] || 0) : 0;
+            if (kind === 'project' && !projectId) return;
+            try {
+                await store.resolveGap(gap.id, kind, projectId);
+            } catch (err) {
+                console.error('Failed to resolve gap:', err);
+            }
+        };
+
+        onMounted(async () => {
+            await Promise.all([store.fetchGaps(), projectsStore.fetchProjects()]);
+        });
+
+        return {
+            gaps: store.state,
+            projects: projectsStore.state,
+            selectedProject,
+            formatRange,
+            formatDuration,
+            resolve
+        };
+    },
+    template: `
+        <div v-if="gaps.gaps.length > 0" class="p-4 border-b border-neutral-800 space-y-3">
+            <h2 class="text-xs font-medium text-neutral-500 uppercase tracking-wide">What were you doing?</h2>
+            <div v-for="gap in gaps.gaps" :key="gap.id" class="rounded-md bg-neutral-900 p-3 space-y-2">
+                <div class="flex items-baseline justify-between gap-2">
+                    <span class="text-xs font-mono text-neutral-400">{{ formatRange(gap) }}</span>
+                    <span class="text-xs text-neutral-500">{{ formatDuration(gap) }}</span>
+                </div>
+                <div class="flex gap-1">
+                    <select v-model="selectedProject[gap.id]"
+                            class="flex-1 min-w-0 bg-neutral-800 text-xs text-neutral-300 rounded px-2 py-1 border border-neutral-700">
+                        <option :value="undefined" disabled>Project...</option>
+                        <option v-for="project in projects.projects" :key="project.id" :value="project.id">{{ project.name }}</option>
+                    </select>
+                    <button @click="resolve(gap, 'project')" :disabled="!selectedProject[gap.id]"
+                            class="text-xs px-2 py-1 rounded bg-neutral-800 text-neutral-300 hover:bg-neutral-700 disabled:opacity-40">
+                        Assign
+                    </button>
+                </div>
+                <div class="flex gap-1">
+                    <button @click="resolve(gap, 'break')" class="flex-1 text-xs px-2 py-1 rounded bg-neutral-800 text-neutral-400 hover:bg-neutral-700">Break</button>
+                    <button @click="resolve(gap, 'meeting')" class="flex-1 text-xs px-2 py-1 rounded bg-neutral-800 text-neutral-400 hover:bg-neutral-700">Meeting</button>
+                    <button @click="resolve(gap, 'discard')" class="flex-1 text-xs px-2 py-1 rounded bg-neutral-800 text-neutral-500 hover:bg-neutral-700">Discard</button>
+                </div>
+            </div>
+        </div>
+    `
+};
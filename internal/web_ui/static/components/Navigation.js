@@ -1,4 +1,7 @@
+import GapPrompt from "./GapPrompt.js";
+
 export default {
+    components: {GapPrompt},
     template: `
         <nav class="p-4 space-y-2 border-b border-neutral-800">
             <router-link to="/" class="flex items-center gap-3 px-3 py-2 text-sm font-medium rounded-md transition-colors"
@@ -29,5 +32,6 @@ export default {
                 Configuration
             </router-link>
         </nav>
+        <GapPrompt />
     `
 };
\ No newline at end of file
@@ -0,0 +1,50 @@
+import { reactive, readonly } from 'vue';
+
+const state = reactive({
+    gaps: [],
+    isLoading: false,
+    error: null
+});
+
+// Fetch the away periods waiting to be resolved
+const fetchGaps = async () => {
+    state.isLoading = true;
+    state.error = null;
+    try {
+        const response = await fetch('/api/gaps');
+        if (!response.ok) throw new Error('Failed to fetch gaps');
+        const data = await response.json();
+        state.gaps = data.gaps || [];
+    } catch (err) {
+        state.error = err.message;
+        console.error(err);
+    } finally {
+        state.isLoading = false;
+    }
+};
+
+// Resolve a gap: kind is project (with project_id), break, meeting or discard
+const resolveGap = async (id, kind, projectId = 0, note = '') => {
+    try {
+        const response = await fetch('/api/gaps/resolve', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id, kind, project_id: projectId, note })
+        });
+        if (!response.ok) throw new Error('Failed to resolve gap');
+
+        // Update local state
+        state.gaps = state.gaps.filter(g => g.id !== id);
+    } catch (err) {
+        state.error = err.message;
+        throw err;
+    }
+};
+
+export const useGapsStore = () => {
+    return {
+        state: readonly(state),
+        fetchGaps,
+        resolveGap
+    };
+};
@@ -135,7 +135,7 @@ export default {
                 result.push({
                     ...item,
                     type: 'entry',
-                    id: s.id, // Ensure top-level ID for keying
+                    id: `${s.id}-${s.start_at}`, // Ensure top-level ID for keying (manual entries can split a span)
                     categories,
                     projects,
                     isNewProj
@@ -384,7 +384,7 @@ export default {
                                         <!-- Details -->
                                         <div class="min-w-0 flex-1 flex items-center gap-2 overflow-hidden">
                                              <span class="text-xs font-medium text-neutral-300 whitespace-nowrap">{{ item.span.app_name }}</span>
-                                             <span class="text-[11px] text-neutral-500 truncate font-light ml-1">{{ item.span.window_title || 'Untitled' }}</span>
+                                             <span class="text-[11px] text-neutral-500 truncate font-light ml-1" :title="item.span.raw_window_title || null">{{ item.span.window_title || 'Untitled' }}</span>
                                         </div>
                                         
                                         <!-- Dots Container -->
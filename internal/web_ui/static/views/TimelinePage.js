@@ -287,7 +287,7 @@ export default {
         // --- Activity selection handler ---
         const handleSelectActivity = (app, span, isWindow, windowName) => {
             // Find the full timelineSpan object that matches this span
-            const timelineSpan = app.fullSpans.find(ts => ts.span.id === span.id);
+            const timelineSpan = app.fullSpans.find(ts => ts.span.id === span.id && ts.span.start_at === span.start_at);
             if (timelineSpan) {
                 store.selectSpan(timelineSpan);
             }
@@ -3,6 +3,7 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -21,7 +22,7 @@ func WrapJSONInOut[I, O any](fn func(ctx context.Context, in I) (*O, error)) htt
 		output, err := fn(r.Context(), input)
 		if err != nil {
 			slog.Error("Internal error", "error", err, "url", r.URL.String(), "input", input)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), errorStatus(err))
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -40,7 +41,7 @@ func WrapJSONIn[I any](fn func(ctx context.Context, in I) error) http.HandlerFun
 		}
 		if err := fn(r.Context(), input); err != nil {
 			slog.Error("Internal error", "error", err, "url", r.URL.String(), "input", input)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), errorStatus(err))
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -53,7 +54,7 @@ func WrapJSONOut[O any](fn func(ctx context.Context) (*O, error)) http.HandlerFu
 		output, err := fn(r.Context())
 		if err != nil {
 			slog.Error("Internal error", "error", err, "url", r.URL.String())
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), errorStatus(err))
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -66,9 +67,30 @@ func WrapJSONNone(fn func(ctx context.Context) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := fn(r.Context()); err != nil {
 			slog.Error("Internal error", "error", err, "url", r.URL.String())
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), errorStatus(err))
 			return
 		}
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// badRequestError marks an error as caused by the request, see BadRequest.
+type badRequestError struct {
+	err error
+}
+
+func (e badRequestError) Error() string { return e.err.Error() }
+func (e badRequestError) Unwrap() error { return e.err }
+
+// BadRequest marks err as caused by the request, e.g. an id that doesn't exist, so the wrapped function responds with
+// 400 Bad Request instead of 500 Internal Server Error.
+func BadRequest(err error) error {
+	return badRequestError{err: err}
+}
+
+func errorStatus(err error) int {
+	if errors.As(err, new(badRequestError)) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,77 @@
+// Package unixsock listens on unix sockets only the current user can connect to.
+package unixsock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const dialTimeout = time.Second
+
+// ErrInUse is returned by Listen when another process answers on the socket.
+var ErrInUse = errors.New("socket in use")
+
+// InUse reports whether a process answers on the unix socket at path.
+func InUse(path string) bool {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// Listen listens on the unix socket at path, with permissions for the current user only. A socket left behind by a
+// process that didn't shut down cleanly is replaced, one that still answers returns ErrInUse.
+func Listen(ctx context.Context, path string) (net.Listener, error) {
+	if InUse(path) {
+		return nil, fmt.Errorf("%w: %s", ErrInUse, path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("remove stale socket: %w", err)
+	}
+
+	// bind in a directory only the current user can enter and move the socket into place once it has its
+	// permissions, so nobody can connect between the bind and the chmod
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".sock-")
+	if err != nil {
+		return nil, fmt.Errorf("create socket dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	tmpPath := filepath.Join(dir, "s")
+
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "unix", tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmpPath, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("move socket: %w", err)
+	}
+	return &listener{Listener: ln, path: path}, nil
+}
+
+// listener removes the socket file when it's first closed, which the net package only does for the path it bound.
+type listener struct {
+	net.Listener
+	path   string
+	remove sync.Once
+}
+
+func (l *listener) Close() error {
+	err := l.Listener.Close()
+	l.remove.Do(func() { _ = os.Remove(l.path) })
+	return err
+}
@@ -0,0 +1,60 @@
+package unixsock
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.sock")
+
+	// a socket nothing listens on anymore, like after a crash
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	ln, err := Listen(ctx, path)
+	if err != nil {
+		t.Fatalf("Listen() on a stale socket error = %v", err)
+	}
+	defer ln.Close()
+
+	if _, err := Listen(ctx, path); !errors.Is(err, ErrInUse) {
+		t.Errorf("Listen() on a live socket error = %v, want %v", err, ErrInUse)
+	}
+	if !InUse(path) {
+		t.Errorf("InUse() = false, want true")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %s, want a socket with permissions 0600", info.Mode())
+	}
+	// only the socket is left in the directory
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the socket", len(entries))
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat() after Close() error = %v, want %v", err, os.ErrNotExist)
+	}
+}